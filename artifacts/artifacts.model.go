@@ -0,0 +1,29 @@
+package artifacts
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrNotFound is returned when an artifact does not exist in the store
+var ErrNotFound = errors.New("artifact not found")
+
+// Limits caps the size of the artifacts kept for an execution
+type Limits struct {
+	// MaxFileSize is the maximum size in bytes of one artifact
+	MaxFileSize int64
+	// MaxTotalSize is the maximum size in bytes of all the artifacts of an execution
+	MaxTotalSize int64
+}
+
+// Store saves the files produced by the executions of connectors
+type Store interface {
+	Save(group string, connector string, execution string, name string, content []byte) error
+	Get(group string, connector string, execution string, name string) ([]byte, error)
+	Delete(group string, connector string, execution string, names []string) error
+}
+
+// IsSafeName tells if a name can be used as a path element of an artifact
+func IsSafeName(name string) bool {
+	return name != "" && name != "." && name != ".." && !strings.ContainsAny(name, "/\\\x00")
+}
@@ -0,0 +1,65 @@
+package artifacts
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// FileStore keeps artifacts on the filesystem, in <root>/<group>/<connector>/<execution>/<name>
+type FileStore struct {
+	Root string
+}
+
+func NewFileStore(root string) (*FileStore, error) {
+	err := os.MkdirAll(root, 0750)
+	if err != nil {
+		return nil, err
+	}
+	return &FileStore{Root: root}, nil
+}
+
+func (s *FileStore) dir(group string, connector string, execution string) (string, error) {
+	for _, element := range []string{group, connector, execution} {
+		if !IsSafeName(element) {
+			return "", fmt.Errorf("Invalid artifact path element %q", element)
+		}
+	}
+	return filepath.Join(s.Root, group, connector, execution), nil
+}
+
+func (s *FileStore) Save(group string, connector string, execution string, name string, content []byte) error {
+	dir, err := s.dir(group, connector, execution)
+	if err != nil {
+		return err
+	}
+	if !IsSafeName(name) {
+		return fmt.Errorf("Invalid artifact name %q", name)
+	}
+	err = os.MkdirAll(dir, 0750)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, name), content, 0640)
+}
+
+func (s *FileStore) Get(group string, connector string, execution string, name string) ([]byte, error) {
+	dir, err := s.dir(group, connector, execution)
+	if err != nil || !IsSafeName(name) {
+		return nil, ErrNotFound
+	}
+	content, err := ioutil.ReadFile(filepath.Join(dir, name))
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	return content, err
+}
+
+func (s *FileStore) Delete(group string, connector string, execution string, names []string) error {
+	dir, err := s.dir(group, connector, execution)
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(dir)
+}
@@ -0,0 +1,56 @@
+package artifacts
+
+import (
+	"github.com/soprasteria/intools-engine/common/keys"
+	"github.com/soprasteria/intools-engine/intools"
+	"gopkg.in/redis.v3"
+)
+
+// RedisStore keeps artifacts in Redis, next to the other keys of the connector
+type RedisStore struct{}
+
+func NewRedisStore() *RedisStore {
+	return &RedisStore{}
+}
+
+func GetRedisArtifactKey(group string, connector string, execution string, name string) string {
+	return keys.Artifact(group, connector, execution, name)
+}
+
+func (s *RedisStore) Save(group string, connector string, execution string, name string, content []byte) error {
+	r, err := intools.Engine.GetRedisClient()
+	if err != nil {
+		return err
+	}
+	return r.Set(GetRedisArtifactKey(group, connector, execution, name), string(content), 0).Err()
+}
+
+func (s *RedisStore) Get(group string, connector string, execution string, name string) ([]byte, error) {
+	r, err := intools.Engine.GetRedisClient()
+	if err != nil {
+		return nil, err
+	}
+	content, err := r.Get(GetRedisArtifactKey(group, connector, execution, name)).Result()
+	if err == redis.Nil {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return []byte(content), nil
+}
+
+func (s *RedisStore) Delete(group string, connector string, execution string, names []string) error {
+	if len(names) == 0 {
+		return nil
+	}
+	r, err := intools.Engine.GetRedisClient()
+	if err != nil {
+		return err
+	}
+	keys := make([]string, len(names))
+	for i, name := range names {
+		keys[i] = GetRedisArtifactKey(group, connector, execution, name)
+	}
+	return intools.RedisDel(r, keys...)
+}
@@ -0,0 +1,177 @@
+package backends
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/fsouza/go-dockerclient"
+	"github.com/orcaman/concurrent-map"
+	"github.com/soprasteria/dockerapi"
+)
+
+const dockerPollInterval = 1 * time.Second
+
+// DockerBackend runs connectors as containers on a Docker host
+type DockerBackend struct {
+	Client     *dockerapi.Client
+	Host       string
+	containers cmap.ConcurrentMap
+}
+
+func NewDockerBackend(client *dockerapi.Client, host string) *DockerBackend {
+	return &DockerBackend{Client: client, Host: host, containers: cmap.New()}
+}
+
+func (b *DockerBackend) Create(spec *Spec) (*Instance, error) {
+	name := spec.Options.Name
+
+	//Get all containers
+	simpleContainers, err := b.Client.ListContainers()
+	if err != nil {
+		log.WithError(err).Error("Cannot list containers")
+		return nil, err
+	}
+
+	//Searching for the container with the same name, and remove it
+	for _, c := range simpleContainers.GetAll() {
+		if c.Name() == fmt.Sprintf("/%s", name) {
+			previousContainerID := c.ID()
+			log.Infof("Removing container %s [/%s]", previousContainerID[:11], name)
+			removeContainerOptions := docker.RemoveContainerOptions{ID: previousContainerID, RemoveVolumes: true, Force: true}
+			err = b.Client.Docker.RemoveContainer(removeContainerOptions)
+			if err != nil {
+				log.WithError(err).Error("Cannot remove container " + previousContainerID[:11])
+				return nil, err
+			}
+		}
+	}
+
+	options := spec.Options
+	options.Labels = map[string]string{}
+	for k, v := range spec.Options.Labels {
+		options.Labels[k] = v
+	}
+	for k, v := range spec.Labels() {
+		options.Labels[k] = v
+	}
+
+	log.Debug("New container with config ", options)
+	container, err := b.Client.NewContainer(options)
+	if err != nil {
+		log.WithError(err).Error("Cannot create container " + name)
+		return nil, err
+	}
+	b.containers.Set(container.ID(), container)
+
+	return &Instance{ID: container.ID(), Name: name, Host: b.Host}, nil
+}
+
+func (b *DockerBackend) Start(instance *Instance) error {
+	tmp, ok := b.containers.Get(instance.ID)
+	if !ok {
+		return fmt.Errorf("Unknown container %s", instance.ID)
+	}
+	container := tmp.(*dockerapi.Container)
+
+	log.Info("Starting container " + instance.Name)
+	// we don't want to force pulling of images in order to support projects which don't have a registry because images are only local in that case
+	forcePull := false
+	err := container.Run(forcePull)
+	if err != nil {
+		log.WithError(err).Error("Cannot start container " + instance.Name)
+		return err
+	}
+	return nil
+}
+
+func (b *DockerBackend) Wait(instance *Instance, timeout time.Duration) (*State, error) {
+	deadline := time.Now().Add(timeout)
+	timedOut := false
+	for {
+		//Each time inspect the container
+		inspect, err := b.Client.InspectContainer(instance.ID)
+		if err != nil {
+			log.WithError(err).Error("Cannot inspect container " + instance.Name)
+			return nil, err
+		}
+		if !inspect.IsRunning() {
+			log.Debug(instance.Name + " is stopped")
+			return &State{
+				ExitCode:   inspect.Container.State.ExitCode,
+				StartedAt:  inspect.Container.State.StartedAt,
+				FinishedAt: inspect.Container.State.FinishedAt,
+				TimedOut:   timedOut,
+			}, nil
+		}
+		if !timedOut && time.Now().After(deadline) {
+			log.Warnf("%s is still running after %s, stopping it", instance.Name, timeout)
+			timedOut = true
+			err = b.Client.Docker.StopContainer(instance.ID, 0)
+			if err != nil {
+				log.WithError(err).Error("Cannot stop container " + instance.Name)
+				return nil, err
+			}
+			continue
+		}
+		log.Debug(instance.Name + " is running...")
+		time.Sleep(dockerPollInterval)
+	}
+}
+
+func (b *DockerBackend) Logs(instance *Instance, stdout io.Writer, stderr io.Writer) error {
+	logOptions := docker.LogsOptions{
+		Container:    instance.ID,
+		OutputStream: stdout,
+		ErrorStream:  stderr,
+		Stdout:       true,
+		Stderr:       true,
+		Tail:         "all",
+		Follow:       true,
+		Timestamps:   false,
+	}
+	return b.Client.Docker.Logs(logOptions)
+}
+
+func (b *DockerBackend) CopyArtifacts(instance *Instance, dir string, w io.Writer) error {
+	return b.Client.Docker.DownloadFromContainer(instance.ID, docker.DownloadFromContainerOptions{
+		Path:         dir,
+		OutputStream: w,
+	})
+}
+
+func (b *DockerBackend) ListInstances(engineId string) ([]*LabelledInstance, error) {
+	containers, err := b.Client.Docker.ListContainers(docker.ListContainersOptions{
+		All:     true,
+		Filters: map[string][]string{"label": {LabelEngine + "=" + engineId}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	instances := make([]*LabelledInstance, len(containers))
+	for i, c := range containers {
+		name := ""
+		if len(c.Names) > 0 {
+			name = strings.TrimPrefix(c.Names[0], "/")
+		}
+		instances[i] = &LabelledInstance{
+			Instance: Instance{ID: c.ID, Name: name, Host: b.Host},
+			Labels:   c.Labels,
+			Created:  time.Unix(c.Created, 0),
+		}
+	}
+	return instances, nil
+}
+
+func (b *DockerBackend) Remove(instance *Instance) error {
+	b.containers.Remove(instance.ID)
+	removeContainerOptions := docker.RemoveContainerOptions{ID: instance.ID, RemoveVolumes: false, Force: true}
+	err := b.Client.Docker.RemoveContainer(removeContainerOptions)
+	if err != nil {
+		log.WithError(err).Error("Cannot remove container " + instance.Name)
+		return err
+	}
+	return nil
+}
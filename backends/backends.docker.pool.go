@@ -0,0 +1,234 @@
+package backends
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/orcaman/concurrent-map"
+	"gopkg.in/yaml.v2"
+)
+
+// DockerHostConfig is the configuration of one Docker host of the pool
+type DockerHostConfig struct {
+	Host      string   `yaml:"host"`
+	Cert      string   `yaml:"cert,omitempty"`
+	TLSVerify *bool    `yaml:"tls-verify,omitempty"`
+	Groups    []string `yaml:"groups,omitempty"`
+}
+
+// IsTLSVerified tells if the certificate of the host has to be checked, true by default
+func (h DockerHostConfig) IsTLSVerified() bool {
+	return h.TLSVerify == nil || *h.TLSVerify
+}
+
+// DockerPoolConfig is the file describing the Docker hosts of the pool
+type DockerPoolConfig struct {
+	Hosts []DockerHostConfig `yaml:"hosts"`
+}
+
+// LoadDockerPoolConfig reads the YAML description of the Docker hosts
+func LoadDockerPoolConfig(path string) (*DockerPoolConfig, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	config := &DockerPoolConfig{}
+	err = yaml.Unmarshal(content, config)
+	if err != nil {
+		return nil, err
+	}
+	if len(config.Hosts) == 0 {
+		return nil, fmt.Errorf("No Docker host declared in %s", path)
+	}
+	return config, nil
+}
+
+// DockerPoolMember is a Docker host of the pool, with the groups pinned to it
+type DockerPoolMember struct {
+	Backend *DockerBackend
+	Groups  []string
+	healthy bool
+	running int
+}
+
+// DockerPool spreads executions across the healthy Docker hosts, on the least loaded one.
+// A group pinned to some hosts only runs on these hosts, other groups run on any host.
+type DockerPool struct {
+	Members   []*DockerPoolMember
+	instances cmap.ConcurrentMap
+	mutex     sync.Mutex
+}
+
+func NewDockerPool(members []*DockerPoolMember) *DockerPool {
+	for _, m := range members {
+		m.healthy = true
+	}
+	return &DockerPool{Members: members, instances: cmap.New()}
+}
+
+// CheckHealth pings every host, taking unreachable hosts out of rotation and putting back the recovered ones
+func (p *DockerPool) CheckHealth() {
+	for _, m := range p.Members {
+		err := m.Backend.Client.Docker.Ping()
+		p.mutex.Lock()
+		wasHealthy := m.healthy
+		m.healthy = err == nil
+		p.mutex.Unlock()
+		if err != nil && wasHealthy {
+			log.WithError(err).WithField("host", m.Backend.Host).Warn("Docker host is unhealthy, taking it out of rotation")
+		} else if err == nil && !wasHealthy {
+			log.WithField("host", m.Backend.Host).Info("Docker host is healthy again, putting it back in rotation")
+		}
+	}
+}
+
+// StartHealthChecks periodically checks the health of the hosts
+func (p *DockerPool) StartHealthChecks(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	go func() {
+		for _ = range ticker.C {
+			p.CheckHealth()
+		}
+	}()
+}
+
+func isPinned(m *DockerPoolMember, group string) bool {
+	for _, g := range m.Groups {
+		if g == group {
+			return true
+		}
+	}
+	return false
+}
+
+// pick selects the least loaded healthy host allowed for the group, and books an execution on it
+func (p *DockerPool) pick(group string) (*DockerPoolMember, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	candidates := []*DockerPoolMember{}
+	for _, m := range p.Members {
+		if isPinned(m, group) {
+			candidates = append(candidates, m)
+		}
+	}
+	if len(candidates) == 0 {
+		candidates = p.Members
+	}
+
+	var selected *DockerPoolMember
+	for _, m := range candidates {
+		if m.healthy && (selected == nil || m.running < selected.running) {
+			selected = m
+		}
+	}
+	if selected == nil {
+		return nil, errors.New("No healthy Docker host available for group " + group)
+	}
+	selected.running++
+	return selected, nil
+}
+
+func (p *DockerPool) release(m *DockerPoolMember) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	m.running--
+}
+
+func (p *DockerPool) get(instance *Instance) (*DockerPoolMember, error) {
+	tmp, ok := p.instances.Get(instance.ID)
+	if !ok {
+		return nil, fmt.Errorf("Unknown container %s", instance.ID)
+	}
+	return tmp.(*DockerPoolMember), nil
+}
+
+func (p *DockerPool) Create(spec *Spec) (*Instance, error) {
+	member, err := p.pick(spec.Group)
+	if err != nil {
+		log.WithError(err).Error("Cannot select a Docker host")
+		return nil, err
+	}
+	log.WithField("host", member.Backend.Host).Debug("Docker host selected for " + spec.Options.Name)
+
+	instance, err := member.Backend.Create(spec)
+	if err != nil {
+		p.release(member)
+		return nil, err
+	}
+	p.instances.Set(instance.ID, member)
+	return instance, nil
+}
+
+func (p *DockerPool) Start(instance *Instance) error {
+	member, err := p.get(instance)
+	if err != nil {
+		return err
+	}
+	return member.Backend.Start(instance)
+}
+
+func (p *DockerPool) Wait(instance *Instance, timeout time.Duration) (*State, error) {
+	member, err := p.get(instance)
+	if err != nil {
+		return nil, err
+	}
+	return member.Backend.Wait(instance, timeout)
+}
+
+func (p *DockerPool) Logs(instance *Instance, stdout io.Writer, stderr io.Writer) error {
+	member, err := p.get(instance)
+	if err != nil {
+		return err
+	}
+	return member.Backend.Logs(instance, stdout, stderr)
+}
+
+func (p *DockerPool) CopyArtifacts(instance *Instance, dir string, w io.Writer) error {
+	member, err := p.get(instance)
+	if err != nil {
+		return err
+	}
+	return member.Backend.CopyArtifacts(instance, dir, w)
+}
+
+func (p *DockerPool) Remove(instance *Instance) error {
+	member, err := p.get(instance)
+	if err != nil {
+		// Instance found by ListInstances, not created by this engine run
+		for _, m := range p.Members {
+			if m.Backend.Host == instance.Host {
+				return m.Backend.Remove(instance)
+			}
+		}
+		return err
+	}
+	p.instances.Remove(instance.ID)
+	p.release(member)
+	return member.Backend.Remove(instance)
+}
+
+// ListInstances lists the instances of the engine on every healthy host
+func (p *DockerPool) ListInstances(engineId string) ([]*LabelledInstance, error) {
+	instances := []*LabelledInstance{}
+	for _, m := range p.Members {
+		p.mutex.Lock()
+		healthy := m.healthy
+		p.mutex.Unlock()
+		if !healthy {
+			continue
+		}
+		hostInstances, err := m.Backend.ListInstances(engineId)
+		if err != nil {
+			log.WithError(err).WithField("host", m.Backend.Host).Warn("Cannot list containers of Docker host")
+			continue
+		}
+		instances = append(instances, hostInstances...)
+	}
+	return instances, nil
+}
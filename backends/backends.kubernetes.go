@@ -0,0 +1,380 @@
+package backends
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/orcaman/concurrent-map"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+const (
+	kubernetesPollInterval = 1 * time.Second
+	// Extra time given to the cluster to report the end of a job after its active deadline
+	kubernetesDeadlineGrace = 30 * time.Second
+	kubernetesContainerName = "connector"
+)
+
+var invalidKubernetesName = regexp.MustCompile("[^a-z0-9-]+")
+
+// KubernetesBackend runs connectors as Kubernetes Jobs, with one pod and no retry
+type KubernetesBackend struct {
+	Client    kubernetes.Interface
+	Namespace string
+	Host      string
+	jobs      cmap.ConcurrentMap
+}
+
+func NewKubernetesBackend(client kubernetes.Interface, namespace string, host string) *KubernetesBackend {
+	return &KubernetesBackend{Client: client, Namespace: namespace, Host: host, jobs: cmap.New()}
+}
+
+// NewKubernetesBackendFromConfig connects to the cluster of the kubeconfig file, or to the current cluster when empty
+func NewKubernetesBackendFromConfig(kubeconfig string, namespace string) (*KubernetesBackend, error) {
+	config, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
+	if err != nil {
+		log.WithError(err).WithField("kubeconfig", kubeconfig).Error("Unable to load Kubernetes configuration")
+		return nil, err
+	}
+	client, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		log.WithError(err).Error("Unable to create Kubernetes client")
+		return nil, err
+	}
+	version, err := client.Discovery().ServerVersion()
+	if err != nil {
+		log.WithError(err).WithField("host", config.Host).Error("Unable to reach Kubernetes cluster")
+		return nil, err
+	}
+	log.WithField("namespace", namespace).Info("Connected to Kubernetes cluster " + config.Host)
+	log.Debug("Kubernetes Version: " + version.GitVersion)
+	return NewKubernetesBackend(client, namespace, config.Host), nil
+}
+
+// GetJobName returns a valid Kubernetes name (DNS-1123 label) from a container name
+func GetJobName(name string) string {
+	jobName := invalidKubernetesName.ReplaceAllString(strings.ToLower(name), "-")
+	jobName = strings.Trim(jobName, "-")
+	if len(jobName) > 63 {
+		jobName = strings.Trim(jobName[:63], "-")
+	}
+	return jobName
+}
+
+// kubernetesOptions are the fields of the container options which have an equivalent in a pod
+var kubernetesOptions = map[string]bool{
+	"Name":       true,
+	"Image":      true,
+	"Entrypoint": true,
+	"Cmd":        true,
+	"Env":        true,
+	"WorkingDir": true,
+	"User":       true,
+	"Labels":     true,
+}
+
+// unsupportedOptions returns the names of the fields set in the options which are not supported, looking into
+// embedded structs
+func unsupportedOptions(options interface{}, supported map[string]bool) []string {
+	return unsupportedFields(reflect.ValueOf(options), supported)
+}
+
+func unsupportedFields(v reflect.Value, supported map[string]bool) []string {
+	unsupported := []string{}
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			unsupported = append(unsupported, unsupportedFields(v.Field(i), supported)...)
+			continue
+		}
+		if !supported[field.Name] && field.IsExported() && !v.Field(i).IsZero() {
+			unsupported = append(unsupported, field.Name)
+		}
+	}
+	return unsupported
+}
+
+// getSecurityContext maps the user of a Docker container, a uid optionally followed by a gid. Pods cannot run
+// as a user name, which is resolved by Docker in the image.
+func getSecurityContext(user string) (*corev1.SecurityContext, error) {
+	if user == "" {
+		return nil, nil
+	}
+	parts := strings.SplitN(user, ":", 2)
+	uid, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("user %q must be a numeric uid on Kubernetes", user)
+	}
+	securityContext := &corev1.SecurityContext{RunAsUser: &uid}
+	if len(parts) == 2 {
+		gid, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("group of user %q must be a numeric gid on Kubernetes", user)
+		}
+		securityContext.RunAsGroup = &gid
+	}
+	return securityContext, nil
+}
+
+// GetJob maps the options of a connector container to a Job, and returns an error for the options which cannot be
+// expressed in a pod. The values of the labels are altered to be valid label values, they are kept as they are
+// in the annotations.
+func (b *KubernetesBackend) GetJob(spec *Spec) (*batchv1.Job, error) {
+	if unsupported := unsupportedOptions(spec.Options, kubernetesOptions); len(unsupported) > 0 {
+		return nil, fmt.Errorf("options %s are not supported by the Kubernetes backend", strings.Join(unsupported, ", "))
+	}
+
+	labels := map[string]string{"app.kubernetes.io/managed-by": "intools-engine"}
+	annotations := map[string]string{}
+	for k, v := range spec.Options.Labels {
+		if errs := validation.IsQualifiedName(k); len(errs) > 0 {
+			return nil, fmt.Errorf("label %q is not a valid Kubernetes label : %s", k, strings.Join(errs, ", "))
+		}
+		labels[k] = GetJobName(v)
+		annotations[k] = v
+	}
+	for k, v := range spec.Labels() {
+		labels[k] = GetJobName(v)
+		annotations[k] = v
+	}
+
+	env := make([]corev1.EnvVar, 0, len(spec.Options.Env))
+	for _, e := range spec.Options.Env {
+		parts := strings.SplitN(e, "=", 2)
+		envVar := corev1.EnvVar{Name: parts[0]}
+		if len(parts) == 2 {
+			envVar.Value = parts[1]
+		}
+		env = append(env, envVar)
+	}
+
+	securityContext, err := getSecurityContext(spec.Options.User)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := int64(spec.Timeout / time.Second)
+	if deadline <= 0 {
+		deadline = 1
+	}
+	backoffLimit := int32(0)
+
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        GetJobName(spec.Options.Name),
+			Namespace:   b.Namespace,
+			Labels:      labels,
+			Annotations: annotations,
+		},
+		Spec: batchv1.JobSpec{
+			ActiveDeadlineSeconds: &deadline,
+			BackoffLimit:          &backoffLimit,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels, Annotations: annotations},
+				Spec: corev1.PodSpec{
+					RestartPolicy: corev1.RestartPolicyNever,
+					Containers: []corev1.Container{
+						{
+							Name:  kubernetesContainerName,
+							Image: spec.Options.Image,
+							// The entrypoint of a Docker container replaces the one of the image, as the command of
+							// a pod does, and its command is given as arguments to the entrypoint
+							Command:         spec.Options.Entrypoint,
+							Args:            spec.Options.Cmd,
+							Env:             env,
+							WorkingDir:      spec.Options.WorkingDir,
+							SecurityContext: securityContext,
+						},
+					},
+				},
+			},
+		},
+	}, nil
+}
+
+func (b *KubernetesBackend) Create(spec *Spec) (*Instance, error) {
+	job, err := b.GetJob(spec)
+	if err != nil {
+		log.WithError(err).Error("Cannot create job for " + spec.Options.Name)
+		return nil, err
+	}
+
+	// Remove the leftover job of a previous execution
+	err = b.deleteJob(job.Name)
+	if err != nil && !apierrors.IsNotFound(err) {
+		log.WithError(err).Error("Cannot remove job " + job.Name)
+		return nil, err
+	}
+
+	b.jobs.Set(job.Name, job)
+	return &Instance{ID: job.Name, Name: job.Name, Host: b.Host}, nil
+}
+
+func (b *KubernetesBackend) Start(instance *Instance) error {
+	tmp, ok := b.jobs.Get(instance.ID)
+	if !ok {
+		return fmt.Errorf("Unknown job %s", instance.ID)
+	}
+	job := tmp.(*batchv1.Job)
+
+	log.Info("Starting job " + instance.Name)
+	created, err := b.Client.BatchV1().Jobs(b.Namespace).Create(context.TODO(), job, metav1.CreateOptions{})
+	if err != nil {
+		log.WithError(err).Error("Cannot create job " + instance.Name)
+		return err
+	}
+	b.jobs.Set(instance.ID, created)
+	return nil
+}
+
+func (b *KubernetesBackend) Wait(instance *Instance, timeout time.Duration) (*State, error) {
+	deadline := time.Now().Add(timeout + kubernetesDeadlineGrace)
+	for {
+		job, err := b.Client.BatchV1().Jobs(b.Namespace).Get(context.TODO(), instance.ID, metav1.GetOptions{})
+		if err != nil {
+			log.WithError(err).Error("Cannot get job " + instance.Name)
+			return nil, err
+		}
+
+		finished, timedOut := isJobFinished(job)
+		if !finished && time.Now().After(deadline) {
+			log.Warnf("%s is still running after %s, stopping it", instance.Name, timeout)
+			finished, timedOut = true, true
+		}
+		if finished {
+			log.Debug(instance.Name + " is stopped")
+			state := &State{TimedOut: timedOut, ExitCode: -1}
+			pod, err := b.getPod(instance)
+			if err == nil {
+				fillStateFromPod(state, pod)
+			} else {
+				log.WithError(err).Warn("Cannot get pod of job " + instance.Name)
+			}
+			return state, nil
+		}
+
+		log.Debug(instance.Name + " is running...")
+		time.Sleep(kubernetesPollInterval)
+	}
+}
+
+// isJobFinished tells if a job is over, and if it has been stopped by its active deadline
+func isJobFinished(job *batchv1.Job) (bool, bool) {
+	for _, condition := range job.Status.Conditions {
+		if condition.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch condition.Type {
+		case batchv1.JobComplete:
+			return true, false
+		case batchv1.JobFailed:
+			return true, condition.Reason == "DeadlineExceeded"
+		}
+	}
+	return job.Status.Succeeded > 0 || job.Status.Failed > 0, false
+}
+
+func fillStateFromPod(state *State, pod *corev1.Pod) {
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Name != kubernetesContainerName || status.State.Terminated == nil {
+			continue
+		}
+		terminated := status.State.Terminated
+		state.ExitCode = int(terminated.ExitCode)
+		state.StartedAt = terminated.StartedAt.Time
+		state.FinishedAt = terminated.FinishedAt.Time
+	}
+}
+
+// getPod returns the pod of the job created for the instance. The pods are selected by the uid of the job, as
+// the pods of a previous job with the same name may still be terminating.
+func (b *KubernetesBackend) getPod(instance *Instance) (*corev1.Pod, error) {
+	var uid string
+	if tmp, ok := b.jobs.Get(instance.ID); ok {
+		uid = string(tmp.(*batchv1.Job).UID)
+	}
+	if uid == "" {
+		job, err := b.Client.BatchV1().Jobs(b.Namespace).Get(context.TODO(), instance.ID, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		uid = string(job.UID)
+	}
+	pods, err := b.Client.CoreV1().Pods(b.Namespace).List(context.TODO(), metav1.ListOptions{
+		LabelSelector: "controller-uid=" + uid,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(pods.Items) == 0 {
+		return nil, fmt.Errorf("No pod found for job %s", instance.ID)
+	}
+	return &pods.Items[0], nil
+}
+
+// Logs copies the logs of the pod to stdout, as Kubernetes does not keep stderr apart
+func (b *KubernetesBackend) Logs(instance *Instance, stdout io.Writer, stderr io.Writer) error {
+	pod, err := b.getPod(instance)
+	if err != nil {
+		return err
+	}
+	request := b.Client.CoreV1().Pods(b.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{Container: kubernetesContainerName})
+	stream, err := request.Stream(context.TODO())
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+	_, err = io.Copy(stdout, stream)
+	return err
+}
+
+func (b *KubernetesBackend) Remove(instance *Instance) error {
+	b.jobs.Remove(instance.ID)
+	err := b.deleteJob(instance.ID)
+	if err != nil && !apierrors.IsNotFound(err) {
+		log.WithError(err).Error("Cannot remove job " + instance.Name)
+		return err
+	}
+	return nil
+}
+
+func (b *KubernetesBackend) ListInstances(engineId string) ([]*LabelledInstance, error) {
+	jobs, err := b.Client.BatchV1().Jobs(b.Namespace).List(context.TODO(), metav1.ListOptions{
+		LabelSelector: LabelEngine + "=" + GetJobName(engineId),
+	})
+	if err != nil {
+		return nil, err
+	}
+	instances := make([]*LabelledInstance, len(jobs.Items))
+	for i, job := range jobs.Items {
+		// The annotations keep the names of the group and the connector, the labels only their lowercase form
+		labels := job.Annotations
+		if _, ok := labels[LabelConnector]; !ok {
+			labels = job.Labels
+		}
+		instances[i] = &LabelledInstance{
+			Instance: Instance{ID: job.Name, Name: job.Name, Host: b.Host},
+			Labels:   labels,
+			Created:  job.CreationTimestamp.Time,
+		}
+	}
+	return instances, nil
+}
+
+func (b *KubernetesBackend) deleteJob(name string) error {
+	propagation := metav1.DeletePropagationBackground
+	return b.Client.BatchV1().Jobs(b.Namespace).Delete(context.TODO(), name, metav1.DeleteOptions{PropagationPolicy: &propagation})
+}
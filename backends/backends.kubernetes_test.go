@@ -0,0 +1,319 @@
+package backends
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/soprasteria/dockerapi"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+const testNamespace = "intools"
+
+func newTestSpec(timeout time.Duration) *Spec {
+	return &Spec{
+		EngineId:    "Engine_1",
+		ExecutionId: "exec1",
+		Group:       "CDK",
+		Connector:   "Hello_World",
+		Options: dockerapi.ContainerOptions{
+			Name:  "CDK_Hello_World",
+			Image: "debian:jessie",
+			Cmd:   []string{"echo", `{"value":1}`},
+			Env:   []string{"A=1", "B=x=y", "EMPTY"},
+		},
+		Timeout: timeout,
+	}
+}
+
+// newTestBackend returns a backend on a fake cluster, which gives a uid to the jobs it creates as a real one does
+func newTestBackend() (*KubernetesBackend, *fake.Clientset) {
+	client := fake.NewSimpleClientset()
+	client.PrependReactor("create", "jobs", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		job := action.(k8stesting.CreateAction).GetObject().(*batchv1.Job)
+		job.UID = types.UID("uid-" + job.Name + "-" + time.Now().Format("150405.000000000"))
+		return false, nil, nil
+	})
+	return NewKubernetesBackend(client, testNamespace, "cluster"), client
+}
+
+// addPod adds a terminated pod to the fake cluster, as created by the job with the uid
+func addPod(t *testing.T, client *fake.Clientset, name string, jobName string, uid types.UID, exitCode int32) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: testNamespace,
+			Labels:    map[string]string{"job-name": jobName, "controller-uid": string(uid)},
+		},
+		Status: corev1.PodStatus{
+			ContainerStatuses: []corev1.ContainerStatus{{
+				Name:  kubernetesContainerName,
+				State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: exitCode}},
+			}},
+		},
+	}
+	if _, err := client.CoreV1().Pods(testNamespace).Create(context.TODO(), pod, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("Cannot create pod %s: %s", name, err)
+	}
+}
+
+// finishJob sets the condition of the end of the job
+func finishJob(t *testing.T, client *fake.Clientset, name string, condition batchv1.JobConditionType, reason string) *batchv1.Job {
+	job, err := client.BatchV1().Jobs(testNamespace).Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Cannot get job %s: %s", name, err)
+	}
+	job.Status.Conditions = append(job.Status.Conditions, batchv1.JobCondition{
+		Type:   condition,
+		Status: corev1.ConditionTrue,
+		Reason: reason,
+	})
+	job, err = client.BatchV1().Jobs(testNamespace).UpdateStatus(context.TODO(), job, metav1.UpdateOptions{})
+	if err != nil {
+		t.Fatalf("Cannot update job %s: %s", name, err)
+	}
+	return job
+}
+
+func startJob(t *testing.T, b *KubernetesBackend, spec *Spec) *Instance {
+	instance, err := b.Create(spec)
+	if err != nil {
+		t.Fatalf("Create failed: %s", err)
+	}
+	if err = b.Start(instance); err != nil {
+		t.Fatalf("Start failed: %s", err)
+	}
+	return instance
+}
+
+func TestGetJob(t *testing.T) {
+	b, _ := newTestBackend()
+	job, err := b.GetJob(newTestSpec(90 * time.Second))
+	if err != nil {
+		t.Fatalf("GetJob failed: %s", err)
+	}
+
+	if job.Name != "cdk-hello-world" || job.Namespace != testNamespace {
+		t.Errorf("Unexpected job %s/%s", job.Namespace, job.Name)
+	}
+	if job.Labels[LabelGroup] != "cdk" || job.Labels[LabelConnector] != "hello-world" || job.Labels[LabelExecution] != "exec1" {
+		t.Errorf("Unexpected labels %v", job.Labels)
+	}
+	if job.Annotations[LabelGroup] != "CDK" || job.Annotations[LabelConnector] != "Hello_World" {
+		t.Errorf("Expected the names of the group and the connector in the annotations, got %v", job.Annotations)
+	}
+	if *job.Spec.BackoffLimit != 0 || job.Spec.Template.Spec.RestartPolicy != corev1.RestartPolicyNever {
+		t.Error("Expected a job without retry")
+	}
+
+	containers := job.Spec.Template.Spec.Containers
+	if len(containers) != 1 {
+		t.Fatalf("Expected one container, got %d", len(containers))
+	}
+	container := containers[0]
+	if container.Image != "debian:jessie" || len(container.Args) != 2 || container.Args[1] != `{"value":1}` {
+		t.Errorf("Unexpected container %s %v", container.Image, container.Args)
+	}
+	expected := []corev1.EnvVar{{Name: "A", Value: "1"}, {Name: "B", Value: "x=y"}, {Name: "EMPTY"}}
+	if len(container.Env) != len(expected) {
+		t.Fatalf("Unexpected env %v", container.Env)
+	}
+	for i, env := range expected {
+		if container.Env[i] != env {
+			t.Errorf("Expected env %v, got %v", env, container.Env[i])
+		}
+	}
+}
+
+func TestGetJobDeadline(t *testing.T) {
+	b, _ := newTestBackend()
+	for timeout, expected := range map[time.Duration]int64{90 * time.Second: 90, 1500 * time.Millisecond: 1, 0: 1} {
+		job, err := b.GetJob(newTestSpec(timeout))
+		if err != nil {
+			t.Fatalf("GetJob failed: %s", err)
+		}
+		if deadline := *job.Spec.ActiveDeadlineSeconds; deadline != expected {
+			t.Errorf("Expected a deadline of %d seconds for a timeout of %s, got %d", expected, timeout, deadline)
+		}
+	}
+}
+
+func TestGetJobMapsOptions(t *testing.T) {
+	b, _ := newTestBackend()
+	spec := newTestSpec(10 * time.Second)
+	spec.Options.Entrypoint = []string{"/bin/sh", "-c"}
+	spec.Options.Cmd = []string{"echo {}"}
+	spec.Options.WorkingDir = "/work"
+	spec.Options.User = "1000:50"
+	spec.Options.Labels = map[string]string{"team": "CDK Team", "example.com/tier": "back"}
+	job, err := b.GetJob(spec)
+	if err != nil {
+		t.Fatalf("GetJob failed: %s", err)
+	}
+
+	container := job.Spec.Template.Spec.Containers[0]
+	if !reflect.DeepEqual(container.Command, []string{"/bin/sh", "-c"}) || !reflect.DeepEqual(container.Args, []string{"echo {}"}) {
+		t.Errorf("Expected the entrypoint as command and the command as arguments, got %v %v", container.Command, container.Args)
+	}
+	if container.WorkingDir != "/work" {
+		t.Errorf("Unexpected working directory %q", container.WorkingDir)
+	}
+	if container.SecurityContext == nil || *container.SecurityContext.RunAsUser != 1000 || *container.SecurityContext.RunAsGroup != 50 {
+		t.Errorf("Unexpected security context %v", container.SecurityContext)
+	}
+	for _, meta := range []metav1.ObjectMeta{job.ObjectMeta, job.Spec.Template.ObjectMeta} {
+		if meta.Labels["team"] != "cdk-team" || meta.Labels["example.com/tier"] != "back" || meta.Labels[LabelGroup] != "cdk" {
+			t.Errorf("Unexpected labels %v", meta.Labels)
+		}
+		if meta.Annotations["team"] != "CDK Team" || meta.Annotations[LabelConnector] != "Hello_World" {
+			t.Errorf("Unexpected annotations %v", meta.Annotations)
+		}
+	}
+
+	// The entrypoint of the image is kept without entrypoint in the options
+	job, _ = b.GetJob(newTestSpec(10 * time.Second))
+	if container = job.Spec.Template.Spec.Containers[0]; container.Command != nil || container.SecurityContext != nil {
+		t.Errorf("Expected the entrypoint and the user of the image, got %v %v", container.Command, container.SecurityContext)
+	}
+}
+
+func TestCreateRejectsUnsupportedOptions(t *testing.T) {
+	b, client := newTestBackend()
+	for _, set := range []func(spec *Spec){
+		func(spec *Spec) { spec.Options.User = "nobody" },
+		func(spec *Spec) { spec.Options.User = "1000:staff" },
+		func(spec *Spec) { spec.Options.Labels = map[string]string{"not a key": "x"} },
+	} {
+		spec := newTestSpec(10 * time.Second)
+		set(spec)
+		if _, err := b.Create(spec); err == nil {
+			t.Errorf("Expected options %+v to be rejected", spec.Options)
+		}
+	}
+	if jobs, _ := client.BatchV1().Jobs(testNamespace).List(context.TODO(), metav1.ListOptions{}); len(jobs.Items) != 0 {
+		t.Errorf("Expected no job, got %d", len(jobs.Items))
+	}
+}
+
+func TestUnsupportedOptions(t *testing.T) {
+	type base struct {
+		Image    string
+		Hostname string
+	}
+	type options struct {
+		base
+		Name       string
+		Memory     int64
+		Privileged bool
+		Binds      []string
+		private    string
+	}
+	supported := map[string]bool{"Image": true, "Name": true}
+	tests := []struct {
+		options     options
+		unsupported []string
+	}{
+		{options{base: base{Image: "busybox"}, Name: "n", private: "x"}, []string{}},
+		{options{base: base{Hostname: "h"}}, []string{"Hostname"}},
+		{options{Memory: 1024, Privileged: true, Binds: []string{"/:/host"}}, []string{"Memory", "Privileged", "Binds"}},
+		{options{Binds: []string{}}, []string{"Binds"}},
+	}
+	for _, test := range tests {
+		if unsupported := unsupportedOptions(test.options, supported); !reflect.DeepEqual(unsupported, test.unsupported) {
+			t.Errorf("Expected %v to be unsupported, got %v", test.unsupported, unsupported)
+		}
+	}
+}
+
+func TestWaitReadsThePodOfTheCreatedJob(t *testing.T) {
+	b, client := newTestBackend()
+	instance := startJob(t, b, newTestSpec(10*time.Second))
+	job := finishJob(t, client, instance.ID, batchv1.JobComplete, "")
+
+	// The pod of a previous job with the same name, still terminating
+	addPod(t, client, instance.ID+"-old", instance.ID, "uid-previous", 1)
+	addPod(t, client, instance.ID+"-new", instance.ID, job.UID, 0)
+
+	state, err := b.Wait(instance, 10*time.Second)
+	if err != nil {
+		t.Fatalf("Wait failed: %s", err)
+	}
+	if state.TimedOut {
+		t.Error("Expected the job not to time out")
+	}
+	if state.ExitCode != 0 {
+		t.Errorf("Expected the exit code of the pod of the created job, got %d", state.ExitCode)
+	}
+}
+
+func TestWaitReportsDeadline(t *testing.T) {
+	b, client := newTestBackend()
+	instance := startJob(t, b, newTestSpec(time.Second))
+	job := finishJob(t, client, instance.ID, batchv1.JobFailed, "DeadlineExceeded")
+	addPod(t, client, instance.ID+"-pod", instance.ID, job.UID, 137)
+
+	state, err := b.Wait(instance, time.Second)
+	if err != nil {
+		t.Fatalf("Wait failed: %s", err)
+	}
+	if !state.TimedOut {
+		t.Error("Expected the job to be reported as timed out")
+	}
+	if state.ExitCode != 137 {
+		t.Errorf("Expected exit code 137, got %d", state.ExitCode)
+	}
+}
+
+func TestLogs(t *testing.T) {
+	b, client := newTestBackend()
+	instance := startJob(t, b, newTestSpec(10*time.Second))
+	job := finishJob(t, client, instance.ID, batchv1.JobComplete, "")
+	addPod(t, client, instance.ID+"-pod", instance.ID, job.UID, 0)
+
+	var stdout, stderr bytes.Buffer
+	if err := b.Logs(instance, &stdout, &stderr); err != nil {
+		t.Fatalf("Logs failed: %s", err)
+	}
+	// The fake clientset answers the same logs for every pod
+	if stdout.String() != "fake logs" {
+		t.Errorf("Unexpected stdout %q", stdout.String())
+	}
+	if stderr.Len() != 0 {
+		t.Errorf("Expected no stderr, got %q", stderr.String())
+	}
+}
+
+func TestListInstancesKeepsNames(t *testing.T) {
+	b, _ := newTestBackend()
+	startJob(t, b, newTestSpec(10*time.Second))
+
+	instances, err := b.ListInstances("Engine_1")
+	if err != nil {
+		t.Fatalf("ListInstances failed: %s", err)
+	}
+	if len(instances) != 1 {
+		t.Fatalf("Expected one instance, got %d", len(instances))
+	}
+	labels := instances[0].Labels
+	if labels[LabelGroup] != "CDK" || labels[LabelConnector] != "Hello_World" || labels[LabelTimeout] != "10" {
+		t.Errorf("Expected the names of the group and the connector as given, got %v", labels)
+	}
+}
+
+func TestLogsWithoutPod(t *testing.T) {
+	b, _ := newTestBackend()
+	instance := startJob(t, b, newTestSpec(10*time.Second))
+	var stdout, stderr bytes.Buffer
+	if err := b.Logs(instance, &stdout, &stderr); err == nil {
+		t.Error("Expected an error when the job has no pod")
+	}
+}
@@ -0,0 +1,215 @@
+package backends
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/orcaman/concurrent-map"
+)
+
+// LocalHost is the host recorded for executions run by the local backend
+const LocalHost = "local"
+
+// localWaitDelay is the time given to the processes started by a connector to release its output once it is over
+const localWaitDelay = 5 * time.Second
+
+// LocalBackend runs the command of connectors as local processes, the image is ignored
+type LocalBackend struct {
+	processes cmap.ConcurrentMap
+	counter   uint64
+}
+
+type localProcess struct {
+	cmd    *exec.Cmd
+	dir    string
+	stdout *bytes.Buffer
+	stderr *bytes.Buffer
+	done   chan error
+	state  *State
+}
+
+func NewLocalBackend() *LocalBackend {
+	return &LocalBackend{processes: cmap.New()}
+}
+
+func (b *LocalBackend) Create(spec *Spec) (*Instance, error) {
+	cmd := spec.Options.Cmd
+	if len(cmd) == 0 {
+		return nil, errors.New("Connector has no command to run as a local process")
+	}
+
+	dir, err := ioutil.TempDir("", "intools-"+spec.Options.Name+"-")
+	if err != nil {
+		log.WithError(err).Error("Cannot create working directory for " + spec.Options.Name)
+		return nil, err
+	}
+
+	process := &localProcess{
+		cmd:    exec.Command(cmd[0], cmd[1:]...),
+		dir:    dir,
+		stdout: new(bytes.Buffer),
+		stderr: new(bytes.Buffer),
+		done:   make(chan error, 1),
+	}
+	process.cmd.Dir = dir
+	// The process leads its own group, so that its children are killed with it on timeout
+	process.cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	// Processes left in the background would otherwise hold the output, and Wait, until they end
+	process.cmd.WaitDelay = localWaitDelay
+	process.cmd.Env = getLocalEnv(spec.Options.Env)
+	process.cmd.Stdout = process.stdout
+	process.cmd.Stderr = process.stderr
+
+	id := fmt.Sprintf("local-%d-%d", time.Now().Unix(), atomic.AddUint64(&b.counter, 1))
+	b.processes.Set(id, process)
+
+	return &Instance{ID: id, Name: spec.Options.Name, Host: LocalHost}, nil
+}
+
+// getLocalEnv returns the environment of a process : the env of the connector, with only the PATH of the engine
+// so that its own variables, and its secrets, are not given to the connectors
+func getLocalEnv(env []string) []string {
+	local := []string{}
+	if path, ok := os.LookupEnv("PATH"); ok {
+		local = append(local, "PATH="+path)
+	}
+	return append(local, env...)
+}
+
+func (b *LocalBackend) get(instance *Instance) (*localProcess, error) {
+	tmp, ok := b.processes.Get(instance.ID)
+	if !ok {
+		return nil, fmt.Errorf("Unknown process %s", instance.ID)
+	}
+	return tmp.(*localProcess), nil
+}
+
+func (b *LocalBackend) Start(instance *Instance) error {
+	process, err := b.get(instance)
+	if err != nil {
+		return err
+	}
+	log.Info("Starting process " + instance.Name)
+	process.state = &State{StartedAt: time.Now()}
+	err = process.cmd.Start()
+	if err != nil {
+		log.WithError(err).Error("Cannot start process " + instance.Name)
+		return err
+	}
+	go func() {
+		process.done <- process.cmd.Wait()
+	}()
+	return nil
+}
+
+func (b *LocalBackend) Wait(instance *Instance, timeout time.Duration) (*State, error) {
+	process, err := b.get(instance)
+	if err != nil {
+		return nil, err
+	}
+	if process.state == nil {
+		return nil, fmt.Errorf("Process %s is not started", instance.Name)
+	}
+
+	select {
+	case err = <-process.done:
+	case <-time.After(timeout):
+		log.Warnf("%s is still running after %s, killing it", instance.Name, timeout)
+		process.state.TimedOut = true
+		if killErr := syscall.Kill(-process.cmd.Process.Pid, syscall.SIGKILL); killErr != nil {
+			log.WithError(killErr).Warnf("Cannot kill the processes of %s", instance.Name)
+			process.cmd.Process.Kill()
+		}
+		err = <-process.done
+	}
+	process.state.FinishedAt = time.Now()
+
+	if err == exec.ErrWaitDelay {
+		// The process succeeded, but left children holding its output
+		log.Warnf("%s left processes running after its end", instance.Name)
+		err = nil
+	}
+	if err != nil {
+		exitErr, ok := err.(*exec.ExitError)
+		if !ok {
+			return nil, err
+		}
+		process.state.ExitCode = 1
+		if status, ok := exitErr.Sys().(syscall.WaitStatus); ok {
+			process.state.ExitCode = status.ExitStatus()
+		}
+	}
+	return process.state, nil
+}
+
+func (b *LocalBackend) Logs(instance *Instance, stdout io.Writer, stderr io.Writer) error {
+	process, err := b.get(instance)
+	if err != nil {
+		return err
+	}
+	if _, err = stdout.Write(process.stdout.Bytes()); err != nil {
+		return err
+	}
+	_, err = stderr.Write(process.stderr.Bytes())
+	return err
+}
+
+// CopyArtifacts archives a directory, relative to the working directory of the process
+func (b *LocalBackend) CopyArtifacts(instance *Instance, dir string, w io.Writer) error {
+	process, err := b.get(instance)
+	if err != nil {
+		return err
+	}
+	root := filepath.Join(process.dir, filepath.Clean("/"+dir))
+	prefix := filepath.Base(root)
+
+	archive := tar.NewWriter(w)
+	err = filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil || !info.Mode().IsRegular() {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(filepath.Join(prefix, rel))
+		if err = archive.WriteHeader(header); err != nil {
+			return err
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		_, err = io.Copy(archive, file)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return archive.Close()
+}
+
+func (b *LocalBackend) Remove(instance *Instance) error {
+	process, err := b.get(instance)
+	if err != nil {
+		return err
+	}
+	b.processes.Remove(instance.ID)
+	return os.RemoveAll(process.dir)
+}
@@ -0,0 +1,85 @@
+package backends
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/soprasteria/dockerapi"
+)
+
+func runLocal(t *testing.T, cmd []string, timeout time.Duration) (*State, string) {
+	return runLocalOptions(t, dockerapi.ContainerOptions{Name: "test", Cmd: cmd}, timeout)
+}
+
+func runLocalOptions(t *testing.T, options dockerapi.ContainerOptions, timeout time.Duration) (*State, string) {
+	b := NewLocalBackend()
+	spec := &Spec{Options: options, Timeout: timeout}
+	instance, err := b.Create(spec)
+	if err != nil {
+		t.Fatalf("Create failed: %s", err)
+	}
+	defer b.Remove(instance)
+	if err = b.Start(instance); err != nil {
+		t.Fatalf("Start failed: %s", err)
+	}
+	state, err := b.Wait(instance, timeout)
+	if err != nil {
+		t.Fatalf("Wait failed: %s", err)
+	}
+	var stdout, stderr bytes.Buffer
+	if err = b.Logs(instance, &stdout, &stderr); err != nil {
+		t.Fatalf("Logs failed: %s", err)
+	}
+	return state, stdout.String()
+}
+
+func TestLocalBackendRunsCommand(t *testing.T) {
+	state, stdout := runLocal(t, []string{"sh", "-c", `echo '{"value":1}'; exit 3`}, 10*time.Second)
+	if state.TimedOut {
+		t.Error("Expected the process not to time out")
+	}
+	if state.ExitCode != 3 {
+		t.Errorf("Expected exit code 3, got %d", state.ExitCode)
+	}
+	if stdout != "{\"value\":1}\n" {
+		t.Errorf("Unexpected stdout %q", stdout)
+	}
+}
+
+func TestLocalBackendKillsChildrenOnTimeout(t *testing.T) {
+	start := time.Now()
+	// The shell waits for its child, which holds the output until it is killed too
+	state, _ := runLocal(t, []string{"sh", "-c", "sleep 8; echo done"}, 1*time.Second)
+	if elapsed := time.Since(start); elapsed > 4*time.Second {
+		t.Errorf("Expected Wait to return soon after the timeout, it took %s", elapsed)
+	}
+	if !state.TimedOut {
+		t.Error("Expected the process to time out")
+	}
+	if state.ExitCode == 0 {
+		t.Error("Expected a killed process not to succeed")
+	}
+}
+
+func TestLocalBackendHidesEngineEnv(t *testing.T) {
+	t.Setenv("INTOOLS_TEST_SECRET", "secret")
+	options := dockerapi.ContainerOptions{Name: "test", Cmd: []string{"sh", "-c", "env"}, Env: []string{"CONNECTOR=value"}}
+	_, stdout := runLocalOptions(t, options, 10*time.Second)
+
+	// The shell may export a few variables of its own
+	env := map[string]bool{}
+	for _, variable := range strings.Split(strings.TrimSpace(stdout), "\n") {
+		env[variable] = true
+	}
+	if env["INTOOLS_TEST_SECRET=secret"] {
+		t.Error("Expected the env of the engine not to be given to the process")
+	}
+	for _, variable := range []string{"CONNECTOR=value", "PATH=" + os.Getenv("PATH")} {
+		if !env[variable] {
+			t.Errorf("Expected %s in the env of the process, got %q", variable, stdout)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package backends
+
+import (
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/soprasteria/dockerapi"
+)
+
+// Labels put on every instance created by the engine
+const (
+	LabelEngine    = "intools.engine"
+	LabelGroup     = "intools.group"
+	LabelConnector = "intools.connector"
+	LabelExecution = "intools.execution"
+	LabelTimeout   = "intools.timeout"
+)
+
+// Spec describes one execution of a connector, independently of where it runs
+type Spec struct {
+	EngineId    string
+	ExecutionId string
+	Group       string
+	Connector   string
+	Options     dockerapi.ContainerOptions
+	Timeout     time.Duration
+}
+
+// Labels returns the labels identifying the instance of the execution
+func (s *Spec) Labels() map[string]string {
+	return map[string]string{
+		LabelEngine:    s.EngineId,
+		LabelGroup:     s.Group,
+		LabelConnector: s.Connector,
+		LabelExecution: s.ExecutionId,
+		LabelTimeout:   strconv.Itoa(int(s.Timeout / time.Second)),
+	}
+}
+
+// Instance is the unit created by a backend for an execution (a container, a process...)
+type Instance struct {
+	ID   string
+	Name string
+	Host string
+}
+
+// State is the final state of an instance, once it is not running anymore
+type State struct {
+	ExitCode   int
+	StartedAt  time.Time
+	FinishedAt time.Time
+	TimedOut   bool
+}
+
+// Backend handles the lifecycle of the instances running the connectors
+type Backend interface {
+	// Create prepares a new instance, replacing any leftover instance with the same name
+	Create(spec *Spec) (*Instance, error)
+	// Start launches a created instance
+	Start(instance *Instance) error
+	// Wait blocks until the instance is stopped, stopping it when the timeout is reached
+	Wait(instance *Instance, timeout time.Duration) (*State, error)
+	// Logs copies stdout and stderr of a stopped instance
+	Logs(instance *Instance, stdout io.Writer, stderr io.Writer) error
+	// Remove deletes the instance and everything attached to it
+	Remove(instance *Instance) error
+}
+
+// LabelledInstance is an instance found on a backend, with its labels
+type LabelledInstance struct {
+	Instance
+	Labels  map[string]string
+	Created time.Time
+}
+
+// Sweeper is implemented by the backends able to find the instances left behind by an engine
+type Sweeper interface {
+	// ListInstances returns all the instances labelled with the engine id
+	ListInstances(engineId string) ([]*LabelledInstance, error)
+	// Remove deletes an instance
+	Remove(instance *Instance) error
+}
+
+// ArtifactsCopier is implemented by the backends able to copy files out of a stopped instance
+type ArtifactsCopier interface {
+	// CopyArtifacts writes a tar archive of a directory of the instance, its entries are prefixed by the name of the directory
+	CopyArtifacts(instance *Instance, dir string, w io.Writer) error
+}
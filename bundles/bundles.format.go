@@ -0,0 +1,85 @@
+package bundles
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Formats of bundles
+const (
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+)
+
+// Encode writes the bundle in the given format. YAML uses the same field names as JSON.
+func Encode(b *Bundle, format string) ([]byte, error) {
+	content, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	switch format {
+	case FormatJSON, "":
+		return content, nil
+	case FormatYAML:
+		var v interface{}
+		err = json.Unmarshal(content, &v)
+		if err != nil {
+			return nil, err
+		}
+		return yaml.Marshal(v)
+	}
+	return nil, fmt.Errorf("Unknown bundle format %s", format)
+}
+
+// Decode reads a bundle written in JSON or in YAML
+func Decode(content []byte) (*Bundle, error) {
+	content, err := YAMLToJSON(content)
+	if err != nil {
+		return nil, err
+	}
+
+	b := &Bundle{}
+	err = json.Unmarshal(content, b)
+	if err != nil {
+		return nil, err
+	}
+	if b.Version < 1 || b.Version > Version {
+		return nil, fmt.Errorf("Unsupported bundle version %d, this engine reads versions 1 to %d", b.Version, Version)
+	}
+	return b, nil
+}
+
+// YAMLToJSON converts a YAML document to JSON, JSON documents are returned as is
+func YAMLToJSON(content []byte) ([]byte, error) {
+	content = bytes.TrimSpace(content)
+	if bytes.HasPrefix(content, []byte("{")) {
+		return content, nil
+	}
+	var v interface{}
+	err := yaml.Unmarshal(content, &v)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(jsonValue(v))
+}
+
+// jsonValue converts the maps read from YAML, which have interface{} keys, to maps JSON can encode
+func jsonValue(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(value))
+		for k, item := range value {
+			m[fmt.Sprint(k)] = jsonValue(item)
+		}
+		return m
+	case []interface{}:
+		for i, item := range value {
+			value[i] = jsonValue(item)
+		}
+		return value
+	}
+	return v
+}
@@ -0,0 +1,83 @@
+package bundles
+
+import (
+	"time"
+
+	"github.com/soprasteria/intools-engine/connectors"
+	"github.com/soprasteria/intools-engine/groups"
+)
+
+// Version is the version of the bundles written by this engine
+const Version = 1
+
+// Import modes
+const (
+	// ModeMerge creates and updates the groups and connectors of the bundle, and keeps the others
+	ModeMerge = "merge"
+	// ModeReplace also removes the connectors of the groups of the bundle which are not in the bundle
+	ModeReplace = "replace"
+)
+
+// Actions of the changes of an import
+const (
+	ActionCreate    = "create"
+	ActionUpdate    = "update"
+	ActionDelete    = "delete"
+	ActionUnchanged = "unchanged"
+)
+
+// Bundle is a portable description of groups and their connectors
+type Bundle struct {
+	Version    int           `json:"version"`
+	ExportedAt time.Time     `json:"exportedAt"`
+	Groups     []GroupBundle `json:"groups"`
+}
+
+// GroupBundle is a group of a bundle, with its connectors and optionally their last results
+type GroupBundle struct {
+	Name string `json:"name"`
+	groups.Metadata
+	Defaults   *connectors.Defaults   `json:"defaults,omitempty"`
+	Retention  *connectors.Retention  `json:"retention,omitempty"`
+	Connectors []connectors.Connector `json:"connectors"`
+	// Results are the last results of the connectors, by name of connector
+	Results map[string]*map[string]interface{} `json:"results,omitempty"`
+	// Declared is set on the groups declared by a manifest, their metadata, defaults and retention are
+	// replaced by the declared ones whatever the mode of the import
+	Declared bool `json:"-"`
+}
+
+// Change is a change made, or to make in a dry run, by an import. Connector is empty for changes of a group.
+type Change struct {
+	Group     string `json:"group"`
+	Connector string `json:"connector,omitempty"`
+	Action    string `json:"action"`
+	// Differences are the changed fields of an updated connector
+	Differences []connectors.Difference `json:"differences,omitempty"`
+}
+
+// GroupNotFoundError is returned by Export for a group which does not exist
+type GroupNotFoundError struct {
+	Group string
+}
+
+func (e *GroupNotFoundError) Error() string {
+	return "Group " + e.Group + " does not exist"
+}
+
+// ImportReport describes what an import changes
+type ImportReport struct {
+	Mode    string   `json:"mode"`
+	DryRun  bool     `json:"dryRun"`
+	Changes []Change `json:"changes"`
+	// Results is the number of last results restored
+	Results int `json:"results"`
+}
+
+// ImportOptions tells how a bundle is imported
+type ImportOptions struct {
+	Mode   string
+	DryRun bool
+	// Schedule schedules the imported connectors, when the engine runs them
+	Schedule bool
+}
@@ -0,0 +1,281 @@
+package bundles
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/soprasteria/intools-engine/connectors"
+	"github.com/soprasteria/intools-engine/groups"
+)
+
+// Export returns a bundle of the given groups, or of all the groups when none is given
+func Export(groupNames []string, withResults bool) (*Bundle, error) {
+	if len(groupNames) == 0 {
+		var err error
+		groupNames, err = groups.Repository.GetGroups()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	b := &Bundle{Version: Version, ExportedAt: time.Now().UTC(), Groups: []GroupBundle{}}
+	for _, name := range groupNames {
+		if groups.GetGroup(name, false) == nil {
+			return nil, &GroupNotFoundError{Group: name}
+		}
+		g, err := exportGroup(name, withResults)
+		if err != nil {
+			return nil, err
+		}
+		b.Groups = append(b.Groups, *g)
+	}
+	return b, nil
+}
+
+func exportGroup(name string, withResults bool) (*GroupBundle, error) {
+	retention, err := connectors.GetGroupRetention(name)
+	if err != nil {
+		return nil, err
+	}
+	names, err := connectors.GetConnectorNames(name)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata, err := groups.Repository.GetMetadata(name)
+	if err != nil {
+		return nil, err
+	}
+	defaults, err := connectors.GetGroupDefaults(name)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &GroupBundle{Name: name, Metadata: *metadata, Defaults: defaults, Retention: retention, Connectors: []connectors.Connector{}}
+	if withResults {
+		g.Results = map[string]*map[string]interface{}{}
+	}
+	for _, connectorName := range names {
+		conn, err := connectors.GetConnector(name, connectorName)
+		if err != nil {
+			return nil, err
+		}
+		g.Connectors = append(g.Connectors, *conn)
+		if !withResults {
+			continue
+		}
+		result, err := connectors.Repository.GetLastResult(conn)
+		if err == nil {
+			g.Results[connectorName] = result
+		} else if err != connectors.ErrNotFound {
+			return nil, err
+		}
+	}
+	return g, nil
+}
+
+// Check validates the groups and the connectors of a bundle
+func Check(b *Bundle) error {
+	seen := map[string]bool{}
+	for _, g := range b.Groups {
+		if err := connectors.CheckName(g.Name); err != nil {
+			return fmt.Errorf("Invalid group name : %s", err.Error())
+		}
+		if seen[g.Name] {
+			return fmt.Errorf("Group %s is defined twice", g.Name)
+		}
+		seen[g.Name] = true
+		if errs := groups.CheckMetadata(&g.Metadata); errs != nil {
+			return fmt.Errorf("Invalid metadata of group %s : %s", g.Name, errs.Error())
+		}
+		if errs := connectors.CheckDefaults(g.Defaults); errs != nil {
+			return fmt.Errorf("Invalid defaults of group %s : %s", g.Name, errs.Error())
+		}
+		if err := connectors.CheckRetention(g.Retention); err != nil {
+			return fmt.Errorf("Invalid retention of group %s : %s", g.Name, err.Error())
+		}
+		names := map[string]bool{}
+		for _, conn := range g.Connectors {
+			if names[conn.Name] {
+				return fmt.Errorf("Connector %s is defined twice in group %s", conn.Name, g.Name)
+			}
+			names[conn.Name] = true
+			conn.Group = g.Name
+			if err := checkConnector(&conn); err != nil {
+				return fmt.Errorf("Invalid connector %s:%s : %s", g.Name, conn.Name, err.Error())
+			}
+		}
+	}
+	return nil
+}
+
+func checkConnector(conn *connectors.Connector) error {
+	if errs := connectors.Validate(conn); errs != nil {
+		return errs
+	}
+	return nil
+}
+
+// Import creates and updates the groups and connectors of the bundle. The report lists the changes, which
+// are only previewed with DryRun.
+func Import(b *Bundle, options ImportOptions) (*ImportReport, error) {
+	if options.Mode == "" {
+		options.Mode = ModeMerge
+	}
+	if options.Mode != ModeMerge && options.Mode != ModeReplace {
+		return nil, fmt.Errorf("Unknown import mode %s, expected %s or %s", options.Mode, ModeMerge, ModeReplace)
+	}
+	if err := Check(b); err != nil {
+		return nil, err
+	}
+
+	report := &ImportReport{Mode: options.Mode, DryRun: options.DryRun, Changes: []Change{}}
+	for _, g := range b.Groups {
+		err := importGroup(&g, options, report)
+		if err != nil {
+			return report, err
+		}
+	}
+	if !options.DryRun {
+		log.WithFields(log.Fields{"mode": options.Mode, "changes": len(report.Changes), "results": report.Results}).Info("Bundle imported")
+	}
+	return report, nil
+}
+
+func importGroup(g *GroupBundle, options ImportOptions, report *ImportReport) error {
+	existing := groups.GetGroup(g.Name, false) != nil
+	retention, err := connectors.GetGroupRetention(g.Name)
+	if err != nil {
+		return err
+	}
+	metadata, err := groups.Repository.GetMetadata(g.Name)
+	if err != nil {
+		return err
+	}
+	defaults, err := connectors.GetGroupDefaults(g.Name)
+	if err != nil {
+		return err
+	}
+	// In merge mode, the metadata, the defaults and the retention of the group are kept when the bundle does not set them,
+	// unless the group is declared by a manifest
+	replace := options.Mode == ModeReplace || g.Declared
+	updateRetention := !reflect.DeepEqual(retention, g.Retention) && (g.Retention != nil || replace)
+	updateDefaults := !reflect.DeepEqual(defaults, g.Defaults) && (g.Defaults != nil || replace)
+	updateMetadata := !reflect.DeepEqual(*metadata, g.Metadata) && (!reflect.DeepEqual(g.Metadata, groups.Metadata{}) || replace)
+
+	switch {
+	case !existing:
+		report.Changes = append(report.Changes, Change{Group: g.Name, Action: ActionCreate})
+	case updateRetention || updateDefaults || updateMetadata:
+		report.Changes = append(report.Changes, Change{Group: g.Name, Action: ActionUpdate})
+	default:
+		report.Changes = append(report.Changes, Change{Group: g.Name, Action: ActionUnchanged})
+	}
+	if !options.DryRun {
+		if !existing {
+			if _, err = groups.CreateGroup(g.Name); err != nil {
+				return err
+			}
+		}
+		if updateMetadata {
+			if err = groups.SaveMetadata(g.Name, &g.Metadata); err != nil {
+				return err
+			}
+		}
+		if updateDefaults {
+			if err = connectors.SetGroupDefaults(g.Name, g.Defaults); err != nil {
+				return err
+			}
+		}
+		if updateRetention {
+			if err = connectors.SetGroupRetention(g.Name, g.Retention); err != nil {
+				return err
+			}
+		}
+	}
+
+	names, err := connectors.GetConnectorNames(g.Name)
+	if err != nil {
+		return err
+	}
+	kept := map[string]bool{}
+	for i := range g.Connectors {
+		conn := &g.Connectors[i]
+		conn.Group = g.Name
+		kept[conn.Name] = true
+		err = importConnector(conn, options, report)
+		if err != nil {
+			return err
+		}
+		if result, ok := g.Results[conn.Name]; ok && result != nil {
+			report.Results++
+			if !options.DryRun {
+				if err = connectors.Repository.SaveLastResult(conn, result); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	if options.Mode != ModeReplace {
+		return nil
+	}
+	for _, name := range names {
+		if kept[name] {
+			continue
+		}
+		report.Changes = append(report.Changes, Change{Group: g.Name, Connector: name, Action: ActionDelete})
+		if options.DryRun {
+			continue
+		}
+		conn, err := connectors.GetConnector(g.Name, name)
+		if err != nil {
+			return err
+		}
+		connectors.Scheduler.RemoveJob(conn)
+		err = connectors.RemoveConnector(conn)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func importConnector(conn *connectors.Connector, options ImportOptions, report *ImportReport) error {
+	change := Change{Group: conn.Group, Connector: conn.Name, Action: ActionCreate}
+	exists, err := connectors.Repository.ConnectorExists(conn.Group, conn.Name)
+	if err != nil {
+		return err
+	}
+	if exists {
+		current, err := connectors.GetConnector(conn.Group, conn.Name)
+		if err != nil {
+			return err
+		}
+		change.Action = ActionUpdate
+		if connectors.SameConfig(current, conn) {
+			change.Action = ActionUnchanged
+			conn.Version = current.Version
+		} else if change.Differences, err = connectors.DiffConfigs(current, conn); err != nil {
+			return err
+		}
+	}
+	report.Changes = append(report.Changes, change)
+
+	if options.DryRun {
+		return nil
+	}
+	if change.Action != ActionUnchanged {
+		_, err = connectors.SaveConnectorVersion(conn, "")
+		if err != nil {
+			return err
+		}
+	}
+	// Unchanged connectors keep their schedule, they are scheduled when the engine does not run them yet
+	if options.Schedule && (change.Action != ActionUnchanged || !connectors.Scheduler.IsScheduled(conn)) {
+		connectors.Scheduler.SetJob(conn)
+	}
+	return nil
+}
@@ -1,17 +1,30 @@
 package cli
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	log "github.com/Sirupsen/logrus"
 	"github.com/codegangsta/cli"
+	"github.com/soprasteria/dockerapi"
 
+	"github.com/soprasteria/intools-engine/artifacts"
+	"github.com/soprasteria/intools-engine/backends"
+	"github.com/soprasteria/intools-engine/bundles"
+	"github.com/soprasteria/intools-engine/common/keys"
 	"github.com/soprasteria/intools-engine/common/server"
 	"github.com/soprasteria/intools-engine/common/utils"
 	"github.com/soprasteria/intools-engine/connectors"
 	"github.com/soprasteria/intools-engine/groups"
 	"github.com/soprasteria/intools-engine/intools"
+	"github.com/soprasteria/intools-engine/manifests"
+	"github.com/soprasteria/intools-engine/migrations"
 )
 
 func initLoggers(lvl string) {
@@ -26,6 +39,215 @@ func initLoggers(lvl string) {
 	log.SetFormatter(&log.TextFormatter{})
 }
 
+// getEngine connects to the backend and to the stores
+func getEngine(c *cli.Context) (*intools.IntoolsEngineImpl, error) {
+	engineId := c.GlobalString("engine-id")
+	if engineId == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			log.WithError(err).Error("Cannot get hostname, please set the engine id")
+			return nil, err
+		}
+		engineId = hostname
+	}
+	log.WithField("engineId", engineId).Debug("Engine id")
+
+	backend, dockerClient, dockerHost, err := getBackend(c)
+	if err != nil {
+		return nil, err
+	}
+
+	redisClient, err := connectStore(c)
+	if err != nil {
+		return nil, err
+	}
+
+	connectors.DefaultRetention = connectors.Retention{
+		MaxExecutions: int64(c.GlobalInt("history-size")),
+		MaxAge:        int64(c.GlobalInt("history-max-age")),
+		MaxSize:       int64(c.GlobalInt("history-max-bytes")),
+		ResultTTL:     int64(c.GlobalInt("result-ttl")),
+	}
+	err = connectors.CheckRetention(&connectors.DefaultRetention)
+	if err != nil {
+		log.WithError(err).Error("Invalid retention")
+		return nil, err
+	}
+
+	connectors.ArtifactStore, err = getArtifactStore(c)
+	if err != nil {
+		return nil, err
+	}
+	connectors.ArtifactLimits = artifacts.Limits{
+		MaxFileSize:  int64(c.GlobalInt("artifacts-max-size")),
+		MaxTotalSize: int64(c.GlobalInt("artifacts-max-total")),
+	}
+
+	return &intools.IntoolsEngineImpl{
+		EngineId:     engineId,
+		DockerClient: dockerClient,
+		DockerHost:   dockerHost,
+		Backend:      backend,
+		RedisClient:  redisClient,
+	}, nil
+}
+
+// connectStore sets the repositories, and connects to Redis when it stores something
+func connectStore(c *cli.Context) (intools.RedisWrapper, error) {
+	err := setStore(c)
+	if err != nil {
+		return nil, err
+	}
+
+	var redisClient intools.RedisWrapper
+	if c.GlobalString("store") == "redis" || c.GlobalString("artifacts-store") == "redis" {
+		redisClient, err = getRedis(c)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if c.GlobalString("store") == "redis" {
+		err = migrations.CheckVersion(redisClient)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return redisClient, nil
+}
+
+// getRedis connects to Redis with the client shared by the whole engine
+func getRedis(c *cli.Context) (intools.RedisWrapper, error) {
+	config := intools.RedisConfig{
+		Mode:       c.GlobalString("redis-mode"),
+		Addrs:      strings.Split(c.GlobalString("redis"), ","),
+		MasterName: c.GlobalString("redis-master"),
+		Password:   c.GlobalString("redis-password"),
+		DB:         int64(c.GlobalInt("redis-db")),
+		PoolSize:   c.GlobalInt("redis-pool-size"),
+	}
+	keys.SetPrefix(c.GlobalString("redis-prefix"))
+	client, err := intools.NewRedisClient(config)
+	if err != nil {
+		log.WithError(err).Error("Unable to get Redis client")
+		return nil, err
+	}
+	log.WithFields(log.Fields{"http": c.GlobalString("redis"), "db": c.GlobalString("redis-db"), "mode": config.Mode, "prefix": keys.Prefix()}).Info("Connected to Redis Host")
+	return client, nil
+}
+
+// setStore sets the repositories of groups and connectors selected by --store
+func setStore(c *cli.Context) error {
+	store := c.GlobalString("store")
+	switch store {
+	case "redis":
+		groups.Repository = groups.NewRedisGroupRepository()
+		connectors.Repository = connectors.NewRedisConnectorRepository()
+		return nil
+	case "memory":
+		log.Warn("Groups, connectors and executions are kept in memory and will be lost when the engine stops")
+		groups.Repository = groups.NewMemoryGroupRepository()
+		connectors.Repository = connectors.NewMemoryConnectorRepository()
+		return nil
+	case "file":
+		path := c.GlobalString("store-path")
+		groupRepository, err := groups.NewFileGroupRepository(path)
+		if err != nil {
+			return err
+		}
+		connectorRepository, err := connectors.NewFileConnectorRepository(path)
+		if err != nil {
+			return err
+		}
+		log.WithField("path", path).Info("Using file store")
+		groups.Repository = groupRepository
+		connectors.Repository = connectorRepository
+		return nil
+	}
+	log.WithField("store", store).Error("Incorrect usage, unknown store")
+	return errors.New("Unknown store " + store)
+}
+
+// getArtifactStore returns the store of the artifacts produced by connectors, nil when collection is disabled
+func getArtifactStore(c *cli.Context) (artifacts.Store, error) {
+	store := c.GlobalString("artifacts-store")
+	switch store {
+	case "":
+		log.Info("Artifacts of connectors are not collected")
+		return nil, nil
+	case "file":
+		path := c.GlobalString("artifacts-path")
+		fileStore, err := artifacts.NewFileStore(path)
+		if err != nil {
+			log.WithError(err).WithField("path", path).Error("Unable to create artifacts directory")
+			return nil, err
+		}
+		return fileStore, nil
+	case "redis":
+		return artifacts.NewRedisStore(), nil
+	}
+	log.WithField("store", store).Error("Incorrect usage, unknown artifacts store")
+	return nil, errors.New("Unknown artifacts store " + store)
+}
+
+// getBackend returns the backend running the connectors, with the Docker client when the backend relies on it
+func getBackend(c *cli.Context) (backends.Backend, *dockerapi.Client, string, error) {
+	backend := c.GlobalString("backend")
+	switch backend {
+	case "docker":
+		if c.GlobalString("docker-hosts") != "" {
+			pool, err := getDockerPool(c)
+			if err != nil {
+				return nil, nil, "", err
+			}
+			return pool, nil, "", nil
+		}
+		dockerClient, host, err := utils.GetDockerCient(c)
+		if err != nil {
+			return nil, nil, host, err
+		}
+		return backends.NewDockerBackend(dockerClient, host), dockerClient, host, nil
+	case "kubernetes":
+		backend, err := backends.NewKubernetesBackendFromConfig(c.GlobalString("kubeconfig"), c.GlobalString("kube-namespace"))
+		if err != nil {
+			return nil, nil, "", err
+		}
+		return backend, nil, backend.Host, nil
+	case "local":
+		log.Warn("Connectors are run as local processes, their image is ignored")
+		return backends.NewLocalBackend(), nil, backends.LocalHost, nil
+	}
+	log.WithField("backend", backend).Error("Incorrect usage, unknown backend")
+	return nil, nil, "", errors.New("Unknown backend " + backend)
+}
+
+// getDockerPool connects to the Docker hosts described in the --docker-hosts file
+func getDockerPool(c *cli.Context) (*backends.DockerPool, error) {
+	path := c.GlobalString("docker-hosts")
+	config, err := backends.LoadDockerPoolConfig(path)
+	if err != nil {
+		log.WithError(err).WithField("file", path).Error("Unable to load Docker hosts")
+		return nil, err
+	}
+
+	members := make([]*backends.DockerPoolMember, len(config.Hosts))
+	for i, h := range config.Hosts {
+		dockerClient, err := utils.NewDockerClient(h.Host, h.Cert, h.IsTLSVerified())
+		if err != nil {
+			return nil, err
+		}
+		members[i] = &backends.DockerPoolMember{
+			Backend: backends.NewDockerBackend(dockerClient, h.Host),
+			Groups:  h.Groups,
+		}
+		log.WithFields(log.Fields{"host": h.Host, "groups": h.Groups}).Info("Docker host added to the pool")
+	}
+
+	pool := backends.NewDockerPool(members)
+	pool.CheckHealth()
+	pool.StartHealthChecks(time.Duration(c.GlobalInt("docker-health-interval")) * time.Second)
+	return pool, nil
+}
+
 func daemonAction(c *cli.Context) {
 	port := c.GlobalInt("port")
 	level := c.GlobalString("log-level")
@@ -33,18 +255,27 @@ func daemonAction(c *cli.Context) {
 	logPath := c.GlobalString("log-path")
 	log.Info("Starting Intools-Engine as daemon")
 
-	dockerClient, dockerHost, err := utils.GetDockerCient(c)
+	engine, err := getEngine(c)
 	if err != nil {
 		os.Exit(1)
 	}
 
-	redisClient, err := utils.GetRedis(c)
-	if err != nil {
-		os.Exit(1)
+	d := server.NewDaemon(port, level, engine)
+	d.SetRoutes(logPath)
+
+	// Clean up what a previous run of the engine may have left behind, then keep on cleaning periodically
+	connectors.Sweep()
+	connectors.StartJanitor(time.Duration(c.GlobalInt("janitor-interval")) * time.Second)
+	if interval := c.GlobalInt("compactor-interval"); interval > 0 {
+		groups.StartCompactor(time.Duration(interval) * time.Second)
+	}
+	if interval := c.GlobalInt("reload-interval"); interval > 0 {
+		groups.StartReloader(time.Duration(interval) * time.Second)
+	}
+	if path := c.GlobalString("manifests"); path != "" {
+		manifests.Watch(path, time.Duration(c.GlobalInt("manifests-interval"))*time.Second, c.GlobalBool("manifests-prune"))
 	}
 
-	d := server.NewDaemon(port, level, dockerClient, dockerHost, redisClient)
-	d.SetRoutes(logPath)
 	d.Run()
 }
 
@@ -52,12 +283,7 @@ func runAction(c *cli.Context) {
 	level := c.GlobalString("log-level")
 	initLoggers(level)
 
-	dockerClient, host, err := utils.GetDockerCient(c)
-	if err != nil {
-		os.Exit(1)
-	}
-
-	redisClient, err := utils.GetRedis(c)
+	engine, err := getEngine(c)
 	if err != nil {
 		os.Exit(1)
 	}
@@ -82,13 +308,14 @@ func runAction(c *cli.Context) {
 
 	log.WithFields(log.Fields{"image": image, "commands": cmd}).Debug("Launching...")
 	log.Warn("In command line, connector schedule is not available")
-	intools.Engine = &intools.IntoolsEngineImpl{DockerClient: dockerClient, DockerHost: host, RedisClient: redisClient}
+	intools.Engine = engine
 	connector := connectors.NewConnector(group, conn)
 	connector.Init(image, uint(timeout), 0, cmd)
-	groups.CreateGroup(group)
+	_, err = groups.CreateGroup(group)
 	if err != nil {
 		os.Exit(3)
 	}
+	connectors.SaveConnector(connector)
 	executor, err := connectors.Exec(connector)
 	if err != nil {
 		os.Exit(3)
@@ -97,6 +324,201 @@ func runAction(c *cli.Context) {
 
 }
 
+func migrateAction(c *cli.Context) {
+	initLoggers(c.GlobalString("log-level"))
+
+	r, err := getRedis(c)
+	if err != nil {
+		os.Exit(1)
+	}
+	intools.Engine = &intools.IntoolsEngineImpl{RedisClient: r}
+
+	dryRun := c.Bool("dry-run")
+	steps, err := migrations.Migrate(r, dryRun)
+	for _, step := range steps {
+		fmt.Printf("Version %d : %s\n", step.Version, step.Description)
+		for _, change := range step.Changes {
+			fmt.Printf("  - %s\n", change)
+		}
+	}
+	if err != nil {
+		log.WithError(err).Error("Migration failed")
+		os.Exit(2)
+	}
+	if len(steps) == 0 {
+		fmt.Printf("Redis data is up to date with version %d\n", migrations.CurrentVersion())
+	} else if dryRun {
+		fmt.Println("Dry run, nothing was changed")
+	}
+}
+
+// getStoreEngine connects to the stores only, for commands which do not run connectors
+func getStoreEngine(c *cli.Context) (*intools.IntoolsEngineImpl, error) {
+	redisClient, err := connectStore(c)
+	if err != nil {
+		return nil, err
+	}
+	return &intools.IntoolsEngineImpl{RedisClient: redisClient}, nil
+}
+
+func exportAction(c *cli.Context) {
+	initLoggers(c.GlobalString("log-level"))
+
+	engine, err := getStoreEngine(c)
+	if err != nil {
+		os.Exit(1)
+	}
+	intools.Engine = engine
+
+	b, err := bundles.Export(c.StringSlice("group"), c.Bool("results"))
+	if err != nil {
+		log.WithError(err).Error("Cannot export groups")
+		os.Exit(2)
+	}
+	content, err := bundles.Encode(b, c.String("format"))
+	if err != nil {
+		log.WithError(err).Error("Cannot encode bundle")
+		os.Exit(2)
+	}
+
+	output := c.String("output")
+	if output == "" || output == "-" {
+		os.Stdout.Write(content)
+		return
+	}
+	err = ioutil.WriteFile(output, content, 0644)
+	if err != nil {
+		log.WithError(err).Error("Cannot write bundle")
+		os.Exit(2)
+	}
+}
+
+func printReport(report *bundles.ImportReport) {
+	for _, change := range report.Changes {
+		target := change.Group
+		if change.Connector != "" {
+			target += "/" + change.Connector
+		}
+		fmt.Printf("%-10s %s\n", change.Action, target)
+		for _, difference := range change.Differences {
+			fmt.Printf("%-10s   %s : %s -> %s\n", "", difference.Path, formatValue(difference.From), formatValue(difference.To))
+		}
+	}
+}
+
+// formatValue prints a value of a difference as JSON, or "-" when it is missing
+func formatValue(value interface{}) string {
+	if value == nil {
+		return "-"
+	}
+	b, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(b)
+}
+
+func applyAction(c *cli.Context) {
+	initLoggers(c.GlobalString("log-level"))
+
+	path := c.String("file")
+	if path == "" {
+		log.Error("Incorrect usage, please give the manifests with -f")
+		os.Exit(1)
+	}
+	b, err := manifests.Load(path)
+	if err != nil {
+		log.WithError(err).Error("Invalid manifests")
+		os.Exit(1)
+	}
+
+	engine, err := getStoreEngine(c)
+	if err != nil {
+		os.Exit(1)
+	}
+	intools.Engine = engine
+
+	dryRun := c.Bool("dry-run")
+	report, err := manifests.Apply(b, manifests.ApplyOptions{Prune: c.Bool("prune"), DryRun: dryRun})
+	if report != nil {
+		printReport(report)
+	}
+	if err != nil {
+		log.WithError(err).Error("Apply failed")
+		os.Exit(2)
+	}
+	if dryRun {
+		fmt.Println("Dry run, nothing was changed")
+	}
+}
+
+func importAction(c *cli.Context) {
+	initLoggers(c.GlobalString("log-level"))
+
+	file := c.String("file")
+	var content []byte
+	var err error
+	if file == "" || file == "-" {
+		content, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		content, err = ioutil.ReadFile(file)
+	}
+	if err != nil {
+		log.WithError(err).Error("Cannot read bundle")
+		os.Exit(1)
+	}
+	b, err := bundles.Decode(content)
+	if err != nil {
+		log.WithError(err).Error("Invalid bundle")
+		os.Exit(1)
+	}
+
+	engine, err := getStoreEngine(c)
+	if err != nil {
+		os.Exit(1)
+	}
+	intools.Engine = engine
+
+	dryRun := c.Bool("dry-run")
+	report, err := bundles.Import(b, bundles.ImportOptions{Mode: c.String("mode"), DryRun: dryRun})
+	if report != nil {
+		printReport(report)
+		fmt.Printf("%d results\n", report.Results)
+	}
+	if err != nil {
+		log.WithError(err).Error("Import failed")
+		os.Exit(2)
+	}
+	if dryRun {
+		fmt.Println("Dry run, nothing was changed")
+	}
+}
+
+func copyPrefixAction(c *cli.Context) {
+	initLoggers(c.GlobalString("log-level"))
+
+	from := c.String("from")
+	if from == "" {
+		from = c.GlobalString("redis-prefix")
+	}
+	r, err := getRedis(c)
+	if err != nil {
+		os.Exit(1)
+	}
+
+	dryRun := c.Bool("dry-run")
+	copied, err := migrations.CopyPrefix(r, from, c.String("to"), c.Bool("overwrite"), dryRun)
+	if err != nil {
+		log.WithError(err).Error("Copy failed")
+		os.Exit(2)
+	}
+	if dryRun {
+		fmt.Printf("%d keys would be copied from %s to %s\n", copied, from, c.String("to"))
+	} else {
+		fmt.Printf("%d keys copied from %s to %s\n", copied, from, c.String("to"))
+	}
+}
+
 func testAction(c *cli.Context) {
 	log.Error("Not yet implemented")
 }
@@ -23,12 +23,171 @@ func NewApp() *cli.App {
 			Usage:  "Docker cert path",
 			EnvVar: "DOCKER_CERT_PATH",
 		},
+		cli.BoolTFlag{
+			Name:   "tls-verify",
+			Usage:  "Verify the certificate of the Docker host against the ca.pem of the cert path",
+			EnvVar: "DOCKER_TLS_VERIFY",
+		},
+		cli.StringFlag{
+			Name:   "docker-hosts",
+			Usage:  "YAML file describing a pool of Docker hosts, used instead of --host and --cert",
+			Value:  "",
+			EnvVar: "INTOOLS_DOCKER_HOSTS",
+		},
+		cli.IntFlag{
+			Name:   "docker-health-interval",
+			Usage:  "Interval in seconds between health checks of the pool of Docker hosts",
+			Value:  30,
+			EnvVar: "INTOOLS_DOCKER_HEALTH_INTERVAL",
+		},
+		cli.StringFlag{
+			Name:   "backend",
+			Usage:  "Backend running the connectors (docker, kubernetes, local)",
+			Value:  "docker",
+			EnvVar: "INTOOLS_BACKEND",
+		},
+		cli.StringFlag{
+			Name:   "kubeconfig",
+			Usage:  "Kubernetes configuration file of the cluster running the connectors, in-cluster configuration when empty",
+			Value:  "",
+			EnvVar: "KUBECONFIG",
+		},
+		cli.StringFlag{
+			Name:   "kube-namespace",
+			Usage:  "Kubernetes namespace of the connector jobs",
+			Value:  "default",
+			EnvVar: "INTOOLS_KUBE_NAMESPACE",
+		},
+		cli.StringFlag{
+			Name:   "engine-id",
+			Usage:  "Id of the engine, put on the containers it creates (hostname by default)",
+			Value:  "",
+			EnvVar: "INTOOLS_ENGINE_ID",
+		},
+		cli.IntFlag{
+			Name:   "janitor-interval",
+			Usage:  "Interval in seconds between removals of orphan containers",
+			Value:  300,
+			EnvVar: "INTOOLS_JANITOR_INTERVAL",
+		},
+		cli.IntFlag{
+			Name:   "history-size",
+			Usage:  "Number of executions kept per connector, unlimited when 0",
+			Value:  50,
+			EnvVar: "INTOOLS_HISTORY_SIZE",
+		},
+		cli.IntFlag{
+			Name:   "history-max-age",
+			Usage:  "Age in seconds after which executions are removed from the history, unlimited when 0",
+			Value:  0,
+			EnvVar: "INTOOLS_HISTORY_MAX_AGE",
+		},
+		cli.IntFlag{
+			Name:   "history-max-bytes",
+			Usage:  "Size in bytes of the history of a connector, with logs and artifacts, unlimited when 0",
+			Value:  0,
+			EnvVar: "INTOOLS_HISTORY_MAX_BYTES",
+		},
+		cli.IntFlag{
+			Name:   "result-ttl",
+			Usage:  "Time in seconds after which the result of a connector which has not run is removed, unlimited when 0",
+			Value:  0,
+			EnvVar: "INTOOLS_RESULT_TTL",
+		},
+		cli.IntFlag{
+			Name:   "compactor-interval",
+			Usage:  "Interval in seconds between compactions applying the retention, disabled when 0",
+			Value:  3600,
+			EnvVar: "INTOOLS_COMPACTOR_INTERVAL",
+		},
+		cli.IntFlag{
+			Name:   "reload-interval",
+			Usage:  "Interval in seconds between reloads scheduling the connectors added to the store out of the engine, disabled when 0",
+			Value:  60,
+			EnvVar: "INTOOLS_RELOAD_INTERVAL",
+		},
+		cli.StringFlag{
+			Name:   "artifacts-store",
+			Usage:  "Store of the artifacts produced by connectors (file, redis), artifacts are not collected when empty",
+			Value:  "",
+			EnvVar: "INTOOLS_ARTIFACTS_STORE",
+		},
+		cli.StringFlag{
+			Name:   "artifacts-path",
+			Usage:  "Directory of the file store of artifacts",
+			Value:  "/var/lib/intools-engine/artifacts",
+			EnvVar: "INTOOLS_ARTIFACTS_PATH",
+		},
+		cli.IntFlag{
+			Name:   "artifacts-max-size",
+			Usage:  "Maximum size in bytes of one artifact",
+			Value:  10 << 20,
+			EnvVar: "INTOOLS_ARTIFACTS_MAX_SIZE",
+		},
+		cli.IntFlag{
+			Name:   "artifacts-max-total",
+			Usage:  "Maximum size in bytes of all the artifacts of an execution",
+			Value:  50 << 20,
+			EnvVar: "INTOOLS_ARTIFACTS_MAX_TOTAL",
+		},
+		cli.StringFlag{
+			Name:   "manifests",
+			Usage:  "Directory of manifests the daemon applies each time they change, disabled when empty",
+			EnvVar: "INTOOLS_MANIFESTS",
+		},
+		cli.IntFlag{
+			Name:   "manifests-interval",
+			Usage:  "Interval in seconds between checks of the manifests",
+			Value:  30,
+			EnvVar: "INTOOLS_MANIFESTS_INTERVAL",
+		},
+		cli.BoolFlag{
+			Name:   "manifests-prune",
+			Usage:  "Remove the connectors applied from manifests which are not declared anymore",
+			EnvVar: "INTOOLS_MANIFESTS_PRUNE",
+		},
+		cli.StringFlag{
+			Name:   "store",
+			Usage:  "Store of groups, connectors and executions (redis, memory, file)",
+			Value:  "redis",
+			EnvVar: "INTOOLS_STORE",
+		},
+		cli.StringFlag{
+			Name:   "store-path",
+			Usage:  "Directory of the file store",
+			Value:  "/var/lib/intools-engine/store",
+			EnvVar: "INTOOLS_STORE_PATH",
+		},
 		cli.StringFlag{
 			Name:   "redis",
-			Usage:  "Redis Host",
+			Usage:  "Redis Host, or comma-separated sentinels or cluster nodes",
 			Value:  "localhost:6379",
 			EnvVar: "REDIS_HOST",
 		},
+		cli.StringFlag{
+			Name:   "redis-mode",
+			Usage:  "Redis mode (single, sentinel, cluster)",
+			Value:  "single",
+			EnvVar: "REDIS_MODE",
+		},
+		cli.StringFlag{
+			Name:   "redis-master",
+			Usage:  "Name of the Redis master monitored by the sentinels",
+			Value:  "",
+			EnvVar: "REDIS_MASTER",
+		},
+		cli.IntFlag{
+			Name:   "redis-pool-size",
+			Usage:  "Maximum number of connections to Redis",
+			Value:  1000,
+			EnvVar: "REDIS_POOL_SIZE",
+		},
+		cli.StringFlag{
+			Name:   "redis-prefix",
+			Usage:  "Prefix of all the keys, to share a Redis database between engines",
+			Value:  "intools",
+			EnvVar: "REDIS_PREFIX",
+		},
 		cli.StringFlag{
 			Name:   "redis-password",
 			Usage:  "Redis Password",
@@ -103,6 +262,108 @@ func NewApp() *cli.App {
 			Description: "Daemon",
 			Action:      publishAction,
 		},
+		cli.Command{
+			Name:        "migrate",
+			Usage:       "Upgrade the data in Redis to the key layout of this engine",
+			Description: "Migrate",
+			Action:      migrateAction,
+			Flags: []cli.Flag{
+				cli.BoolFlag{
+					Name:  "dry-run",
+					Usage: "Only print what would be changed",
+				},
+			},
+		},
+		cli.Command{
+			Name:        "apply",
+			Usage:       "Apply the manifests of groups and connectors of a file or a directory",
+			Description: "Apply",
+			Action:      applyAction,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "file, f",
+					Usage: "File or directory of the manifests",
+				},
+				cli.BoolFlag{
+					Name:  "prune",
+					Usage: "Remove the connectors applied from manifests which are not declared anymore",
+				},
+				cli.BoolFlag{
+					Name:  "dry-run",
+					Usage: "Only print what would be changed",
+				},
+			},
+		},
+		cli.Command{
+			Name:        "copy-prefix",
+			Usage:       "Copy the keys of an engine to another prefix in Redis",
+			Description: "Copy prefix",
+			Action:      copyPrefixAction,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "from",
+					Usage: "Prefix of the keys to copy, --redis-prefix when not set",
+				},
+				cli.StringFlag{
+					Name:  "to",
+					Usage: "Prefix of the copies",
+				},
+				cli.BoolFlag{
+					Name:  "overwrite",
+					Usage: "Replace the keys which already exist",
+				},
+				cli.BoolFlag{
+					Name:  "dry-run",
+					Usage: "Only count the keys to copy",
+				},
+			},
+		},
+		cli.Command{
+			Name:        "export",
+			Usage:       "Export groups and connectors as a bundle",
+			Description: "Export",
+			Action:      exportAction,
+			Flags: []cli.Flag{
+				cli.StringSliceFlag{
+					Name:  "group, g",
+					Usage: "Group to export, all groups when not set",
+				},
+				cli.BoolFlag{
+					Name:  "results",
+					Usage: "Export the last results of the connectors",
+				},
+				cli.StringFlag{
+					Name:  "format",
+					Usage: "Format of the bundle : json, yaml",
+					Value: "json",
+				},
+				cli.StringFlag{
+					Name:  "output, o",
+					Usage: "File of the bundle, standard output when not set",
+				},
+			},
+		},
+		cli.Command{
+			Name:        "import",
+			Usage:       "Import groups and connectors from a bundle",
+			Description: "Import",
+			Action:      importAction,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "file, f",
+					Usage: "File of the bundle, standard input when not set",
+				},
+				cli.StringFlag{
+					Name:  "mode",
+					Usage: "merge keeps the connectors missing from the bundle, replace removes them from the groups of the bundle",
+					Value: "merge",
+				},
+				cli.BoolFlag{
+					Name:  "dry-run",
+					Usage: "Only print what would be changed",
+				},
+			},
+		},
 	}
 
 	return app
@@ -0,0 +1,56 @@
+package jsonmerge
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestApply(t *testing.T) {
+	// The examples of the appendix A of RFC 7396
+	tests := []struct {
+		target string
+		patch  string
+		result string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+		// Nested members are merged, the others are kept
+		{`{"config":{"Image":"a","Env":["X=1"]},"timeout":10}`, `{"config":{"Image":"b"}}`, `{"config":{"Image":"b","Env":["X=1"]},"timeout":10}`},
+	}
+	for _, test := range tests {
+		patched, err := Apply([]byte(test.target), []byte(test.patch))
+		if err != nil {
+			t.Errorf("Apply(%s, %s) failed: %s", test.target, test.patch, err)
+			continue
+		}
+		var result, expected interface{}
+		json.Unmarshal(patched, &result)
+		json.Unmarshal([]byte(test.result), &expected)
+		if !reflect.DeepEqual(result, expected) {
+			t.Errorf("Apply(%s, %s) = %s, expected %s", test.target, test.patch, patched, test.result)
+		}
+	}
+}
+
+func TestApplyRejectsInvalidJSON(t *testing.T) {
+	if _, err := Apply([]byte(`{}`), []byte(`{`)); err == nil {
+		t.Error("Expected an invalid patch to fail")
+	}
+	if _, err := Apply([]byte(`{`), []byte(`{}`)); err == nil {
+		t.Error("Expected an invalid target to fail")
+	}
+}
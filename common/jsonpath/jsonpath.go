@@ -0,0 +1,112 @@
+package jsonpath
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Path is a compiled JSONPath expression selecting a single value
+type Path struct {
+	expression string
+	steps      []interface{}
+}
+
+// Compile parses a JSONPath expression. Supported syntax is a subset selecting a single value :
+// the root $, child fields .field or ['field'], and array indexes [0] (negative indexes count from the end).
+func Compile(expression string) (*Path, error) {
+	if !strings.HasPrefix(expression, "$") {
+		return nil, fmt.Errorf("%s: path must start with $", expression)
+	}
+	path := &Path{expression: expression}
+	rest := expression[1:]
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			end := strings.IndexAny(rest[1:], ".[")
+			if end < 0 {
+				end = len(rest) - 1
+			}
+			field := rest[1 : end+1]
+			if field == "" || field == "*" {
+				return nil, fmt.Errorf("%s: invalid field %q", expression, field)
+			}
+			path.steps = append(path.steps, field)
+			rest = rest[end+1:]
+		case '[':
+			end := strings.Index(rest, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("%s: unclosed [", expression)
+			}
+			selector := rest[1:end]
+			if len(selector) >= 2 && (selector[0] == '\'' || selector[0] == '"') && selector[len(selector)-1] == selector[0] {
+				path.steps = append(path.steps, selector[1:len(selector)-1])
+			} else {
+				index, err := strconv.Atoi(selector)
+				if err != nil {
+					return nil, fmt.Errorf("%s: invalid index [%s]", expression, selector)
+				}
+				path.steps = append(path.steps, index)
+			}
+			rest = rest[end+1:]
+		default:
+			return nil, fmt.Errorf("%s: unexpected character %q", expression, rest[0])
+		}
+	}
+	return path, nil
+}
+
+func (p *Path) String() string {
+	return p.expression
+}
+
+// Get returns the value selected by the path in a document decoded by encoding/json
+func (p *Path) Get(document interface{}) (interface{}, error) {
+	value := document
+	for _, step := range p.steps {
+		switch s := step.(type) {
+		case string:
+			object, ok := value.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("%s: cannot get field %s of a non-object", p.expression, s)
+			}
+			value, ok = object[s]
+			if !ok {
+				return nil, fmt.Errorf("%s: no field %s", p.expression, s)
+			}
+		case int:
+			array, ok := value.([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("%s: cannot get index %d of a non-array", p.expression, s)
+			}
+			index := s
+			if index < 0 {
+				index += len(array)
+			}
+			if index < 0 || index >= len(array) {
+				return nil, fmt.Errorf("%s: index %d out of range", p.expression, s)
+			}
+			value = array[index]
+		}
+	}
+	return value, nil
+}
+
+// GetNumber returns the number selected by the path, numeric strings are accepted
+func (p *Path) GetNumber(document interface{}) (float64, error) {
+	value, err := p.Get(document)
+	if err != nil {
+		return 0, err
+	}
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	case string:
+		number, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return 0, fmt.Errorf("%s: %q is not a number", p.expression, v)
+		}
+		return number, nil
+	}
+	return 0, fmt.Errorf("%s: value is not a number", p.expression)
+}
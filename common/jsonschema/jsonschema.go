@@ -0,0 +1,270 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ValidationError describes one violation of a schema, located by a JSONPath-like path ($.field[0].sub)
+type ValidationError struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+func (e ValidationError) Error() string {
+	return e.Path + ": " + e.Message
+}
+
+// Check verifies that a schema only uses supported keywords with well-typed values.
+// Supported keywords are a subset of JSON Schema draft 4 : type, enum, properties, required,
+// additionalProperties, items, minimum, maximum, minLength, maxLength, pattern, minItems, maxItems.
+func Check(schema map[string]interface{}) error {
+	return check("$", schema)
+}
+
+func check(path string, schema map[string]interface{}) error {
+	for keyword, value := range schema {
+		switch keyword {
+		case "$schema", "id", "title", "description", "default":
+		case "type":
+			for _, t := range typesOf(value) {
+				if !isKnownType(t) {
+					return fmt.Errorf("%s: unknown type %q", path, t)
+				}
+			}
+		case "enum":
+			if _, ok := value.([]interface{}); !ok {
+				return fmt.Errorf("%s: enum must be an array", path)
+			}
+		case "required":
+			if _, ok := value.([]interface{}); !ok {
+				return fmt.Errorf("%s: required must be an array", path)
+			}
+		case "properties":
+			properties, ok := value.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("%s: properties must be an object", path)
+			}
+			for name, sub := range properties {
+				subSchema, ok := sub.(map[string]interface{})
+				if !ok {
+					return fmt.Errorf("%s.%s: schema must be an object", path, name)
+				}
+				if err := check(path+"."+name, subSchema); err != nil {
+					return err
+				}
+			}
+		case "additionalProperties":
+			switch v := value.(type) {
+			case bool:
+			case map[string]interface{}:
+				if err := check(path+".*", v); err != nil {
+					return err
+				}
+			default:
+				return fmt.Errorf("%s: additionalProperties must be a boolean or an object", path)
+			}
+		case "items":
+			items, ok := value.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("%s: items must be an object", path)
+			}
+			if err := check(path+"[*]", items); err != nil {
+				return err
+			}
+		case "minimum", "maximum", "minLength", "maxLength", "minItems", "maxItems":
+			if _, ok := value.(float64); !ok {
+				return fmt.Errorf("%s: %s must be a number", path, keyword)
+			}
+		case "pattern":
+			pattern, ok := value.(string)
+			if !ok {
+				return fmt.Errorf("%s: pattern must be a string", path)
+			}
+			if _, err := regexp.Compile(pattern); err != nil {
+				return fmt.Errorf("%s: invalid pattern: %s", path, err.Error())
+			}
+		default:
+			return fmt.Errorf("%s: unsupported keyword %q", path, keyword)
+		}
+	}
+	return nil
+}
+
+// Validate checks a decoded JSON document against a schema and returns every violation found
+func Validate(schema map[string]interface{}, document interface{}) []ValidationError {
+	errs := []ValidationError{}
+	validate("$", schema, document, &errs)
+	return errs
+}
+
+func validate(path string, schema map[string]interface{}, value interface{}, errs *[]ValidationError) {
+	report := func(format string, args ...interface{}) {
+		*errs = append(*errs, ValidationError{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if t, ok := schema["type"]; ok {
+		types := typesOf(t)
+		if !matchesOneType(types, value) {
+			report("expected %s, got %s", strings.Join(types, " or "), typeOf(value))
+			return
+		}
+	}
+
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		found := false
+		for _, candidate := range enum {
+			if equal(candidate, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			report("value is not one of the allowed values")
+		}
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		validateObject(path, schema, v, errs, report)
+	case []interface{}:
+		if min, ok := schema["minItems"].(float64); ok && float64(len(v)) < min {
+			report("expected at least %v items, got %d", min, len(v))
+		}
+		if max, ok := schema["maxItems"].(float64); ok && float64(len(v)) > max {
+			report("expected at most %v items, got %d", max, len(v))
+		}
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range v {
+				validate(fmt.Sprintf("%s[%d]", path, i), items, item, errs)
+			}
+		}
+	case string:
+		length := float64(len([]rune(v)))
+		if min, ok := schema["minLength"].(float64); ok && length < min {
+			report("expected at least %v characters, got %v", min, length)
+		}
+		if max, ok := schema["maxLength"].(float64); ok && length > max {
+			report("expected at most %v characters, got %v", max, length)
+		}
+		if pattern, ok := schema["pattern"].(string); ok {
+			re, err := regexp.Compile(pattern)
+			if err == nil && !re.MatchString(v) {
+				report("value does not match pattern %q", pattern)
+			}
+		}
+	case float64:
+		if min, ok := schema["minimum"].(float64); ok && v < min {
+			report("expected a value >= %v, got %v", min, v)
+		}
+		if max, ok := schema["maximum"].(float64); ok && v > max {
+			report("expected a value <= %v, got %v", max, v)
+		}
+	}
+}
+
+func validateObject(path string, schema map[string]interface{}, object map[string]interface{}, errs *[]ValidationError, report func(string, ...interface{})) {
+	if required, ok := schema["required"].([]interface{}); ok {
+		for _, r := range required {
+			name, _ := r.(string)
+			if _, present := object[name]; !present {
+				report("missing required property %q", name)
+			}
+		}
+	}
+
+	properties, _ := schema["properties"].(map[string]interface{})
+	names := make([]string, 0, len(object))
+	for name := range object {
+		names = append(names, name)
+	}
+	// Keep error ordering stable between executions
+	sort.Strings(names)
+
+	for _, name := range names {
+		propertyPath := path + "." + name
+		if sub, ok := properties[name].(map[string]interface{}); ok {
+			validate(propertyPath, sub, object[name], errs)
+			continue
+		}
+		switch additional := schema["additionalProperties"].(type) {
+		case bool:
+			if !additional {
+				*errs = append(*errs, ValidationError{Path: propertyPath, Message: "additional property is not allowed"})
+			}
+		case map[string]interface{}:
+			validate(propertyPath, additional, object[name], errs)
+		}
+	}
+}
+
+func typesOf(t interface{}) []string {
+	switch v := t.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		types := make([]string, 0, len(v))
+		for _, s := range v {
+			if str, ok := s.(string); ok {
+				types = append(types, str)
+			}
+		}
+		return types
+	}
+	return []string{}
+}
+
+func isKnownType(t string) bool {
+	switch t {
+	case "object", "array", "string", "number", "integer", "boolean", "null":
+		return true
+	}
+	return false
+}
+
+func matchesOneType(types []string, value interface{}) bool {
+	actual := typeOf(value)
+	for _, t := range types {
+		if t == actual || (t == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+func typeOf(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case float64:
+		if v == math.Trunc(v) {
+			return "integer"
+		}
+		return "number"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+func equal(a, b interface{}) bool {
+	ja, err := json.Marshal(a)
+	if err != nil {
+		return false
+	}
+	jb, err := json.Marshal(b)
+	if err != nil {
+		return false
+	}
+	return string(ja) == string(jb)
+}
@@ -0,0 +1,89 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func decode(t *testing.T, s string) interface{} {
+	var v interface{}
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		t.Fatalf("Invalid JSON %s: %s", s, err)
+	}
+	return v
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		schema   string
+		document string
+		errors   []ValidationError
+	}{
+		{"type", `{"type":"object"}`, `[]`, []ValidationError{{"$", "expected object, got array"}}},
+		{"type list", `{"type":["string","null"]}`, `null`, nil},
+		{"integer is a number", `{"type":"number"}`, `3`, nil},
+		{"number is not an integer", `{"type":"integer"}`, `3.5`, []ValidationError{{"$", "expected integer, got number"}}},
+		{"enum", `{"enum":["a",1]}`, `"b"`, []ValidationError{{"$", "value is not one of the allowed values"}}},
+		{"enum match", `{"enum":["a",{"b":[1]}]}`, `{"b":[1]}`, nil},
+		{"required", `{"required":["a","b"]}`, `{"a":1}`, []ValidationError{{"$", `missing required property "b"`}}},
+		{"properties", `{"properties":{"a":{"type":"string"}}}`, `{"a":1,"b":2}`, []ValidationError{{"$.a", "expected string, got integer"}}},
+		{"additional properties", `{"properties":{"a":{}},"additionalProperties":false}`, `{"a":1,"c":2,"b":3}`, []ValidationError{
+			{"$.b", "additional property is not allowed"},
+			{"$.c", "additional property is not allowed"},
+		}},
+		{"additional properties schema", `{"additionalProperties":{"type":"boolean"}}`, `{"a":true,"b":1}`, []ValidationError{{"$.b", "expected boolean, got integer"}}},
+		{"items", `{"items":{"type":"integer"}}`, `[1,"2",3]`, []ValidationError{{"$[1]", "expected integer, got string"}}},
+		{"min items", `{"minItems":2}`, `[1]`, []ValidationError{{"$", "expected at least 2 items, got 1"}}},
+		{"max items", `{"maxItems":1}`, `[1,2]`, []ValidationError{{"$", "expected at most 1 items, got 2"}}},
+		{"min length counts runes", `{"minLength":3}`, `"éé"`, []ValidationError{{"$", "expected at least 3 characters, got 2"}}},
+		{"max length", `{"maxLength":1}`, `"ab"`, []ValidationError{{"$", "expected at most 1 characters, got 2"}}},
+		{"pattern", `{"pattern":"^[a-z]+$"}`, `"A"`, []ValidationError{{"$", `value does not match pattern "^[a-z]+$"`}}},
+		{"minimum", `{"minimum":0}`, `-1`, []ValidationError{{"$", "expected a value >= 0, got -1"}}},
+		{"maximum", `{"maximum":10}`, `10.5`, []ValidationError{{"$", "expected a value <= 10, got 10.5"}}},
+		{"nested", `{"properties":{"a":{"items":{"required":["id"]}}}}`, `{"a":[{"id":1},{}]}`, []ValidationError{{"$.a[1]", `missing required property "id"`}}},
+		{"keywords of other types are ignored", `{"minimum":1,"minLength":1}`, `true`, nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			errs := Validate(decode(t, test.schema).(map[string]interface{}), decode(t, test.document))
+			if test.errors == nil {
+				test.errors = []ValidationError{}
+			}
+			if !reflect.DeepEqual(errs, test.errors) {
+				t.Errorf("Expected %v, got %v", test.errors, errs)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		schema string
+		err    string
+	}{
+		{`{"$schema":"http://json-schema.org/draft-04/schema#","title":"t","type":"object","properties":{"a":{"type":"integer","minimum":0}},"required":["a"]}`, ""},
+		{`{"additionalProperties":{"type":"string"}}`, ""},
+		{`{"type":"date"}`, `$: unknown type "date"`},
+		{`{"enum":"a"}`, "$: enum must be an array"},
+		{`{"required":"a"}`, "$: required must be an array"},
+		{`{"properties":[]}`, "$: properties must be an object"},
+		{`{"properties":{"a":true}}`, "$.a: schema must be an object"},
+		{`{"properties":{"a":{"oneOf":[]}}}`, `$.a: unsupported keyword "oneOf"`},
+		{`{"additionalProperties":1}`, "$: additionalProperties must be a boolean or an object"},
+		{`{"items":[]}`, "$: items must be an object"},
+		{`{"items":{"type":"uuid"}}`, `$[*]: unknown type "uuid"`},
+		{`{"maxLength":"1"}`, "$: maxLength must be a number"},
+		{`{"pattern":1}`, "$: pattern must be a string"},
+		{`{"pattern":"("}`, "$: invalid pattern: error parsing regexp: missing closing ): `(`"},
+	}
+	for _, test := range tests {
+		err := Check(decode(t, test.schema).(map[string]interface{}))
+		if test.err == "" && err != nil {
+			t.Errorf("Expected %s to be supported, got %s", test.schema, err)
+		} else if test.err != "" && (err == nil || err.Error() != test.err) {
+			t.Errorf("Expected %s to fail with %q, got %v", test.schema, test.err, err)
+		}
+	}
+}
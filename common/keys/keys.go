@@ -0,0 +1,113 @@
+package keys
+
+// Layout of the keys of the engine in Redis, its version is kept in the Schema key.
+// All the keys start with the prefix, so that several engines can share a Redis database.
+
+// DefaultPrefix is the prefix of the keys when none is set
+const DefaultPrefix = "intools"
+
+var prefix = DefaultPrefix
+
+// SetPrefix sets the prefix of all the keys, it must be called before Redis is used
+func SetPrefix(p string) {
+	if p == "" {
+		p = DefaultPrefix
+	}
+	prefix = p
+}
+
+// Prefix returns the prefix of all the keys
+func Prefix() string {
+	return prefix
+}
+
+// Schema is the key holding the version of the layout of the keys
+func Schema() string {
+	return prefix + ":schema"
+}
+
+// Groups is the set of the names of the groups
+func Groups() string {
+	return prefix + ":groups"
+}
+
+// Group is the prefix of all the keys of a group
+func Group(group string) string {
+	return Groups() + ":" + group
+}
+
+// Retention is the JSON of the retention of the connectors of a group
+func Retention(group string) string {
+	return Group(group) + ":retention"
+}
+
+// Metadata is the JSON of the description, the owners and the labels of a group
+func Metadata(group string) string {
+	return Group(group) + ":metadata"
+}
+
+// Defaults is the JSON of the default settings of the connectors of a group
+func Defaults(group string) string {
+	return Group(group) + ":defaults"
+}
+
+// GroupVersion is the counter bumped by every change of the metadata, the defaults, the retention or the connectors
+// of a group. It is kept when the group is deleted, so that a group created again does not reuse its versions.
+func GroupVersion(group string) string {
+	return Group(group) + ":version"
+}
+
+// Connectors is the set of the names of the connectors of a group
+func Connectors(group string) string {
+	return Group(group) + ":connectors"
+}
+
+// Connector is the prefix of all the keys of a connector
+func Connector(group string, connector string) string {
+	return Connectors(group) + ":" + connector
+}
+
+// ConnectorConf is the JSON configuration of a connector
+func ConnectorConf(group string, connector string) string {
+	return Connector(group, connector) + ":conf"
+}
+
+// Versions is the sorted set of the JSON versions of the configuration of a connector, scored by version
+func Versions(group string, connector string) string {
+	return Connector(group, connector) + ":versions"
+}
+
+// VersionCounter is the last version number allocated to the configuration of a connector
+func VersionCounter(group string, connector string) string {
+	return Connector(group, connector) + ":version"
+}
+
+// Executor is the JSON of the last execution of a connector
+func Executor(group string, connector string) string {
+	return Connector(group, connector) + ":executors"
+}
+
+// Result is the JSON of the result of the last valid execution of a connector
+func Result(group string, connector string) string {
+	return Connector(group, connector) + ":results"
+}
+
+// Executions is the sorted set of the ids of the executions of a connector, scored by creation time
+func Executions(group string, connector string) string {
+	return Connector(group, connector) + ":executions"
+}
+
+// Execution is the JSON of an execution of a connector
+func Execution(group string, connector string, id string) string {
+	return Executions(group, connector) + ":" + id
+}
+
+// Artifact is the content of an artifact of an execution
+func Artifact(group string, connector string, id string, name string) string {
+	return Execution(group, connector, id) + ":artifacts:" + name
+}
+
+// Series is the sorted set of the points of a series of a connector, scored by time in milliseconds
+func Series(group string, connector string, name string) string {
+	return Connector(group, connector) + ":series:" + name
+}
@@ -0,0 +1,45 @@
+// Package listing describes the pages of the listings of the REST API
+package listing
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MaxLimit is the maximum number of items of a page
+const MaxLimit = 1000
+
+// Page selects the items of a listing once sorted
+type Page struct {
+	// Sort is the field the items are sorted by, in descending order when Desc is set
+	Sort   string
+	Desc   bool
+	Offset int
+	// Limit is the maximum number of items, unlimited when 0
+	Limit int
+}
+
+// ParseSort reads a sort given as a field, prefixed with '-' for the descending order, among the allowed fields
+func ParseSort(sort string, fields ...string) (string, bool, error) {
+	desc := strings.HasPrefix(sort, "-")
+	field := strings.TrimPrefix(sort, "-")
+	for _, f := range fields {
+		if f == field {
+			return field, desc, nil
+		}
+	}
+	return "", false, fmt.Errorf("sort must be one of %s, prefixed with '-' for the descending order", strings.Join(fields, ", "))
+}
+
+// Bounds returns the start and the end of the page among total items
+func (p Page) Bounds(total int) (int, int) {
+	start := p.Offset
+	if start > total {
+		start = total
+	}
+	end := total
+	if p.Limit > 0 && start+p.Limit < end {
+		end = start + p.Limit
+	}
+	return start, end
+}
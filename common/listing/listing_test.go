@@ -0,0 +1,59 @@
+package listing
+
+import "testing"
+
+func TestParseSort(t *testing.T) {
+	tests := []struct {
+		sort  string
+		field string
+		desc  bool
+		valid bool
+	}{
+		{"name", "name", false, true},
+		{"-name", "name", true, true},
+		{"-lastRun", "lastRun", true, true},
+		{"status", "status", false, true},
+		{"--name", "", false, false},
+		{"+name", "", false, false},
+		{"Name", "", false, false},
+		{"-", "", false, false},
+		{"", "", false, false},
+	}
+	for _, test := range tests {
+		field, desc, err := ParseSort(test.sort, "name", "lastRun", "status")
+		if (err == nil) != test.valid {
+			t.Errorf("ParseSort(%q): expected valid %t, got error %v", test.sort, test.valid, err)
+			continue
+		}
+		if field != test.field || desc != test.desc {
+			t.Errorf("ParseSort(%q) = %q, %t, expected %q, %t", test.sort, field, desc, test.field, test.desc)
+		}
+	}
+}
+
+func TestBounds(t *testing.T) {
+	tests := []struct {
+		name  string
+		page  Page
+		total int
+		start int
+		end   int
+	}{
+		{"unlimited", Page{}, 5, 0, 5},
+		{"limit 0 is unlimited", Page{Offset: 2, Limit: 0}, 5, 2, 5},
+		{"first page", Page{Limit: 2}, 5, 0, 2},
+		{"middle page", Page{Offset: 2, Limit: 2}, 5, 2, 4},
+		{"last page is partial", Page{Offset: 4, Limit: 2}, 5, 4, 5},
+		{"offset at the end", Page{Offset: 5, Limit: 2}, 5, 5, 5},
+		{"offset past the end", Page{Offset: 10, Limit: 2}, 5, 5, 5},
+		{"offset past the end unlimited", Page{Offset: 10}, 5, 5, 5},
+		{"no item", Page{Limit: MaxLimit}, 0, 0, 0},
+		{"max limit", Page{Limit: MaxLimit}, MaxLimit + 10, 0, MaxLimit},
+	}
+	for _, test := range tests {
+		start, end := test.page.Bounds(test.total)
+		if start != test.start || end != test.end {
+			t.Errorf("%s: expected [%d, %d), got [%d, %d)", test.name, test.start, test.end, start, end)
+		}
+	}
+}
@@ -5,23 +5,24 @@ import (
 
 	log "github.com/Sirupsen/logrus"
 	"github.com/gin-gonic/gin"
-	"github.com/soprasteria/dockerapi"
 	"github.com/soprasteria/intools-engine/common/websocket"
 	"github.com/soprasteria/intools-engine/controllers"
 	"github.com/soprasteria/intools-engine/groups"
 	"github.com/soprasteria/intools-engine/intools"
-	"gopkg.in/redis.v3"
 
 	"github.com/gin-gonic/contrib/expvar"
 )
 
+// APIPrefix is the prefix of the routes of the current version of the REST API
+const APIPrefix = "/api/v1"
+
 type Daemon struct {
 	Port   int
 	Engine *gin.Engine
 	level  string
 }
 
-func NewDaemon(port int, level string, dockerClient *dockerapi.Client, dockerHost string, redisClient *redis.Client) *Daemon {
+func NewDaemon(port int, level string, intoolsEngine *intools.IntoolsEngineImpl) *Daemon {
 
 	var engine *gin.Engine
 	if level == string(gin.DebugMode) {
@@ -37,7 +38,9 @@ func NewDaemon(port int, level string, dockerClient *dockerapi.Client, dockerHos
 		engine = gin.Default()
 	}
 	engine.Use(gin.Recovery())
-	intools.Engine = &intools.IntoolsEngineImpl{dockerClient, dockerHost, redisClient}
+	engine.Use(controllers.RequestID())
+	engine.NoRoute(controllers.ControllerNoRoute)
+	intools.Engine = intoolsEngine
 	daemon := &Daemon{port, engine, level}
 	length := groups.GetGroupsLength()
 	websocket.InitChannel(length)
@@ -51,30 +54,55 @@ func (d *Daemon) Run() {
 func (d *Daemon) SetRoutes(logPath string) {
 	d.Engine.GET("/websocket", websocket.GetWS)
 	d.Engine.GET("/debug/vars", expvar.Handler())
-	d.Engine.GET("/groups", controllers.ControllerGetGroups)
-	d.Engine.GET("/logs", func(c *gin.Context) { controllers.GetLogs(c, logPath) })
+	d.Engine.GET(APIPrefix+"/openapi.json", controllers.ControllerGetOpenAPI)
+
+	setAPIRoutes(d.Engine.Group(APIPrefix), logPath)
+
+	// Deprecated aliases of the routes, for the clients of the unversioned API
+	legacyRouter := d.Engine.Group("/", controllers.Deprecated(APIPrefix))
+	legacyRouter.GET("/groups/", controllers.ControllerGetGroups)
+	setAPIRoutes(legacyRouter, logPath)
+}
 
-	allGroupRouter := d.Engine.Group("/groups/")
+// setAPIRoutes sets the routes of the REST API, each of them described in openapi.Spec
+func setAPIRoutes(router *gin.RouterGroup, logPath string) {
+	router.GET("/groups", controllers.ControllerGetGroups)
+	router.GET("/logs", func(c *gin.Context) { controllers.GetLogs(c, logPath) })
+	router.GET("/compaction", controllers.ControllerGetCompaction)
+	router.POST("/compaction", controllers.ControllerPostCompaction)
+	router.GET("/export", controllers.ControllerExport)
+	router.POST("/import", controllers.ControllerImport)
+
+	oneGroupRouter := router.Group("/groups/:group")
 	{
-		allGroupRouter.GET("", controllers.ControllerGetGroups)
+		oneGroupRouter.GET("", controllers.ControllerGetGroup)
+		oneGroupRouter.POST("", controllers.ControllerPostGroup)
+		oneGroupRouter.PUT("", controllers.ControllerPutGroup)
+		oneGroupRouter.DELETE("", controllers.ControllerDeleteGroup)
+		oneGroupRouter.GET("/retention", controllers.ControllerGetGroupRetention)
+		oneGroupRouter.PUT("/retention", controllers.ControllerPutGroupRetention)
+		oneGroupRouter.DELETE("/retention", controllers.ControllerDeleteGroupRetention)
 
-		oneGroupRouter := allGroupRouter.Group("/:group")
+		oneGroupConnectorRouter := oneGroupRouter.Group("/connectors")
 		{
-			oneGroupRouter.GET("", controllers.ControllerGetGroup)
-			oneGroupRouter.POST("", controllers.ControllerPostGroup)
-			oneGroupRouter.DELETE("", controllers.ControllerDeleteGroup)
-
-			oneGroupConnectorRouter := oneGroupRouter.Group("/connectors")
-			{
-				oneGroupConnectorRouter.GET("", controllers.ControllerGetConnectors)
-				oneGroupConnectorRouter.GET("/:connector", controllers.ControllerGetConnector)
-				oneGroupConnectorRouter.POST("/:connector", controllers.ControllerCreateConnector)
-				oneGroupConnectorRouter.DELETE("/:connector", controllers.ControllerDeleteConnector)
-				oneGroupConnectorRouter.GET("/:connector/refresh", controllers.ControllerExecConnector)
-				oneGroupConnectorRouter.GET("/:connector/result", controllers.ControllerGetConnectorResult)
-				oneGroupConnectorRouter.GET("/:connector/exec", controllers.ControllerGetConnectorExecutor)
-			}
+			oneGroupConnectorRouter.GET("", controllers.ControllerGetConnectors)
+			oneGroupConnectorRouter.GET("/:connector", controllers.ControllerGetConnector)
+			oneGroupConnectorRouter.POST("/:connector", controllers.ControllerCreateConnector)
+			oneGroupConnectorRouter.PUT("/:connector", controllers.ControllerPutConnector)
+			oneGroupConnectorRouter.PATCH("/:connector", controllers.ControllerPatchConnector)
+			oneGroupConnectorRouter.DELETE("/:connector", controllers.ControllerDeleteConnector)
+			oneGroupConnectorRouter.GET("/:connector/refresh", controllers.ControllerExecConnector)
+			oneGroupConnectorRouter.GET("/:connector/result", controllers.ControllerGetConnectorResult)
+			oneGroupConnectorRouter.GET("/:connector/exec", controllers.ControllerGetConnectorExecutor)
+			oneGroupConnectorRouter.GET("/:connector/status", controllers.ControllerGetConnectorStatus)
+			oneGroupConnectorRouter.GET("/:connector/series/:name", controllers.ControllerGetConnectorSeries)
+			oneGroupConnectorRouter.GET("/:connector/executions", controllers.ControllerGetConnectorExecutions)
+			oneGroupConnectorRouter.GET("/:connector/versions", controllers.ControllerGetConnectorVersions)
+			oneGroupConnectorRouter.GET("/:connector/versions/:version", controllers.ControllerGetConnectorVersion)
+			oneGroupConnectorRouter.GET("/:connector/versions/:version/diff", controllers.ControllerDiffConnectorVersion)
+			oneGroupConnectorRouter.POST("/:connector/versions/:version/rollback", controllers.ControllerRollbackConnector)
+			oneGroupConnectorRouter.GET("/:connector/executions/:id", controllers.ControllerGetConnectorExecution)
+			oneGroupConnectorRouter.GET("/:connector/executions/:id/artifacts/:name", controllers.ControllerGetConnectorArtifact)
 		}
 	}
-
 }
@@ -0,0 +1,54 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/soprasteria/intools-engine/openapi"
+)
+
+// newTestDaemon sets the routes on an engine which is not started
+func newTestDaemon() *Daemon {
+	gin.SetMode(gin.TestMode)
+	d := &Daemon{Engine: gin.New()}
+	d.SetRoutes("")
+	return d
+}
+
+func TestSpecificationDescribesRoutes(t *testing.T) {
+	d := newTestDaemon()
+	problems, err := openapi.Check(d.Engine.Routes(), APIPrefix)
+	if err != nil {
+		t.Fatalf("Check failed: %s", err)
+	}
+	for _, problem := range problems {
+		t.Error(problem)
+	}
+}
+
+func TestSpecificationIsServed(t *testing.T) {
+	d := newTestDaemon()
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", APIPrefix+"/openapi.json", nil)
+	d.Engine.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	if contentType := w.Header().Get("Content-Type"); contentType != "application/json; charset=utf-8" {
+		t.Errorf("Unexpected content type %q", contentType)
+	}
+	if w.Body.String() != openapi.Spec {
+		t.Error("Expected the body to be the specification")
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("Expected a JSON document: %s", err)
+	}
+	if _, ok := doc["paths"]; !ok {
+		t.Error("Expected the document to have paths")
+	}
+}
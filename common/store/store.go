@@ -0,0 +1,140 @@
+package store
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// The file stores keep one file per key under a directory, names are escaped with Escape
+
+// LoadJSON reads a file written by SaveJSON into value, leaving value untouched when the file does not exist yet
+func LoadJSON(path string, value interface{}) error {
+	content, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(content, value)
+}
+
+// SaveJSON writes value to a file, through a temporary file renamed over it so that a crash never leaves a partial file
+func SaveJSON(path string, value interface{}) error {
+	content, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(path), 0750)
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(content)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// AppendJSON appends value to a file as one line of JSON, so that a growing list is written one item at a time
+func AppendJSON(path string, value interface{}) error {
+	content, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(path), 0750)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0640)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(content, '\n'))
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// LoadJSONLines calls f with each line of a file written by AppendJSON, nothing happens when the file does not exist
+func LoadJSONLines(path string, f func(line []byte) error) error {
+	content, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, line := range bytes.Split(content, []byte("\n")) {
+		// A crash may leave the last line partial
+		if len(bytes.TrimSpace(line)) == 0 || !json.Valid(line) {
+			continue
+		}
+		err = f(line)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Remove removes a file or a directory with its content, it is not an error when it does not exist
+func Remove(path string) error {
+	err := os.RemoveAll(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// ListDir returns the names of the entries of a directory, unescaped, none when it does not exist
+func ListDir(dir string) ([]string, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		name, err := url.PathUnescape(entry.Name())
+		if err != nil {
+			continue
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// Escape returns a name usable as one element of a path, whatever its characters
+func Escape(name string) string {
+	escaped := url.PathEscape(name)
+	if strings.HasPrefix(escaped, ".") {
+		escaped = "%2E" + escaped[1:]
+	}
+	return escaped
+}
@@ -0,0 +1,117 @@
+package store
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSaveJSONReplacesFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "sub", "value.json")
+	for _, value := range []map[string]int{{"a": 1}, {"b": 2}} {
+		if err := SaveJSON(path, value); err != nil {
+			t.Fatalf("SaveJSON failed: %s", err)
+		}
+	}
+
+	var loaded map[string]int
+	if err := LoadJSON(path, &loaded); err != nil {
+		t.Fatalf("LoadJSON failed: %s", err)
+	}
+	if !reflect.DeepEqual(loaded, map[string]int{"b": 2}) {
+		t.Errorf("Unexpected value %v", loaded)
+	}
+	entries, _ := ioutil.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Errorf("Expected no temporary file left, got %d entries", len(entries))
+	}
+}
+
+func TestLoadJSONLeavesValueOfMissingFile(t *testing.T) {
+	value := map[string]int{"a": 1}
+	if err := LoadJSON(filepath.Join(t.TempDir(), "missing.json"), &value); err != nil {
+		t.Fatalf("LoadJSON failed: %s", err)
+	}
+	if !reflect.DeepEqual(value, map[string]int{"a": 1}) {
+		t.Errorf("Unexpected value %v", value)
+	}
+}
+
+func TestAppendJSONSkipsPartialLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "series.jsonl")
+	for i := 1; i <= 2; i++ {
+		if err := AppendJSON(path, map[string]int{"v": i}); err != nil {
+			t.Fatalf("AppendJSON failed: %s", err)
+		}
+	}
+	// A crash while appending the third line
+	f, _ := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0640)
+	f.WriteString(`{"v":`)
+	f.Close()
+
+	lines := []string{}
+	err := LoadJSONLines(path, func(line []byte) error {
+		lines = append(lines, string(line))
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("LoadJSONLines failed: %s", err)
+	}
+	if !reflect.DeepEqual(lines, []string{`{"v":1}`, `{"v":2}`}) {
+		t.Errorf("Unexpected lines %v", lines)
+	}
+}
+
+func TestEscapedNamesAreListed(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name    string
+		escaped string
+	}{
+		{"plain", "plain"},
+		{"a/b", "a%2Fb"},
+		{"with space", "with%20space"},
+		{".hidden", "%2Ehidden"},
+		{"..", "%2E."},
+		{"100%", "100%25"},
+	}
+	for _, test := range tests {
+		if escaped := Escape(test.name); escaped != test.escaped {
+			t.Errorf("Escape(%q) = %q, expected %q", test.name, escaped, test.escaped)
+		}
+		if err := SaveJSON(filepath.Join(dir, Escape(test.name)), test.name); err != nil {
+			t.Fatalf("SaveJSON failed: %s", err)
+		}
+	}
+	// Temporary files are not listed
+	ioutil.WriteFile(filepath.Join(dir, ".value.json.tmp123"), nil, 0640)
+
+	names, err := ListDir(dir)
+	if err != nil {
+		t.Fatalf("ListDir failed: %s", err)
+	}
+	listed := map[string]bool{}
+	for _, name := range names {
+		listed[name] = true
+	}
+	if len(names) != len(tests) {
+		t.Errorf("Unexpected names %v", names)
+	}
+	for _, test := range tests {
+		if !listed[test.name] {
+			t.Errorf("Expected %q to be listed", test.name)
+		}
+	}
+	if names, err = ListDir(filepath.Join(dir, "missing")); err != nil || len(names) != 0 {
+		t.Errorf("Expected no name for a missing directory, got %v, %v", names, err)
+	}
+}
+
+func TestRemoveMissingPath(t *testing.T) {
+	if err := Remove(filepath.Join(t.TempDir(), "missing")); err != nil {
+		t.Errorf("Remove failed: %s", err)
+	}
+}
@@ -2,15 +2,22 @@ package tests
 
 import (
 	"github.com/soprasteria/dockerapi"
+	"github.com/soprasteria/intools-engine/backends"
 	"github.com/soprasteria/intools-engine/intools"
 )
 
 type IntoolsEngineMock struct {
+	EngineId     string
 	DockerClient dockerapi.Client
 	DockerHost   string
+	Backend      backends.Backend
 	RedisClient  intools.RedisWrapper
 }
 
+func (e IntoolsEngineMock) GetEngineId() string {
+	return e.EngineId
+}
+
 func (e IntoolsEngineMock) GetDockerClient() *dockerapi.Client {
 	return &e.DockerClient
 }
@@ -19,6 +26,10 @@ func (e IntoolsEngineMock) GetDockerHost() string {
 	return e.DockerHost
 }
 
-func (e IntoolsEngineMock) GetRedisClient() intools.RedisWrapper {
-	return e.RedisClient
+func (e IntoolsEngineMock) GetBackend() backends.Backend {
+	return e.Backend
+}
+
+func (e IntoolsEngineMock) GetRedisClient() (intools.RedisWrapper, error) {
+	return e.RedisClient, nil
 }
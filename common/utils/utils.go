@@ -5,24 +5,17 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"errors"
+	"fmt"
 	"io"
 	"io/ioutil"
-	"os"
 	"path/filepath"
 	"strings"
-	"time"
 	"unicode"
 	"unicode/utf8"
 
 	log "github.com/Sirupsen/logrus"
 	"github.com/codegangsta/cli"
-	"github.com/gin-gonic/gin"
 	"github.com/soprasteria/dockerapi"
-	"gopkg.in/redis.v3"
-)
-
-var (
-	redisOptions *redis.Options
 )
 
 func StringTransform(s string) string {
@@ -63,105 +56,104 @@ func ReadLogs(reader io.Reader) (string, error) {
 	return text, err
 }
 
-func GetRedis(c *cli.Context) (*redis.Client, error) {
-	redisOptions = &redis.Options{
-		Addr:         c.GlobalString("redis"),
-		Password:     c.GlobalString("redis-password"),
-		DB:           int64(c.GlobalInt("redis-db")),
-		PoolSize:     1000,
-		PoolTimeout:  2 * time.Minute,
-		IdleTimeout:  10 * time.Minute,
-		ReadTimeout:  2 * time.Minute,
-		WriteTimeout: 1 * time.Minute,
-		MaxRetries:   10,
-		DialTimeout:  1 * time.Minute,
+func GetDockerCient(c *cli.Context) (*dockerapi.Client, string, error) {
+	host := c.GlobalString("host")
+	if host == "" {
+		log.Error("Incorrect usage, please set the docker host")
+		return nil, "", errors.New("Unable to connect to docker host")
 	}
-
-	client, err := GetRedisClient()
-
+	dockerClient, err := NewDockerClient(host, c.GlobalString("cert"), c.GlobalBool("tls-verify"))
 	if err != nil {
-		log.WithError(err).Error("Unable to get Redis client")
-	} else {
-		log.WithFields(log.Fields{"http": c.GlobalString("redis"), "db": c.GlobalString("redis-db")}).Info("Connected to Redis Host")
+		return nil, host, err
 	}
-
-	return client, err
-}
-
-func GetRedisClient() (*redis.Client, error) {
-	client := redis.NewClient(redisOptions)
-
-	_, err := client.Ping().Result()
+	err = PingDockerClient(dockerClient, host)
 	if err != nil {
-		log.WithError(err).Error("Unable to connect to redis host")
-		return nil, err
+		return nil, host, err
 	}
-
-	return client, nil
+	return dockerClient, host, nil
 }
 
-func GetDockerCient(c *cli.Context) (*dockerapi.Client, string, error) {
-	host := c.GlobalString("host")
-	if host == "" {
-		log.Error("Incorrect usage, please set the docker host")
-		return nil, "", errors.New("Unable to connect to docker host")
+// NewDockerClient creates a client of a Docker host. When certPath is not empty, the client authenticates with
+// cert.pem and key.pem over TLS, and the certificate of the host is checked against ca.pem if tlsVerify is set.
+func NewDockerClient(host string, certPath string, tlsVerify bool) (*dockerapi.Client, error) {
+	if certPath == "" {
+		if tlsVerify {
+			log.WithField("host", host).Warn("TLS verification is enabled but no cert path is set, connecting without TLS")
+		}
+		dockerClient, err := dockerapi.NewClient(host)
+		if err != nil {
+			log.WithError(err).Error("Unable to connect to docker host")
+			return nil, err
+		}
+		return dockerClient, nil
 	}
 
-	tlsConfig := &tls.Config{}
+	certFile := filepath.Join(certPath, "cert.pem")
+	keyFile := filepath.Join(certPath, "key.pem")
+	caFile := filepath.Join(certPath, "ca.pem")
 
-	certPath := c.GlobalString("cert")
-	if certPath != "" {
-		caFile := filepath.Join(certPath, "ca.pem")
-		if _, err := os.Stat(caFile); os.IsNotExist(err) {
-			log.WithField("file", caFile).Error("Cannot open file")
-			log.Error("Incorrect usage, please set correct cert files")
-			return nil, host, errors.New("Unable to connect to docker host")
-		}
+	certPEM, err := readCertFile(certFile)
+	if err != nil {
+		return nil, err
+	}
+	keyPEM, err := readCertFile(keyFile)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = tls.X509KeyPair(certPEM, keyPEM); err != nil {
+		log.WithError(err).WithFields(log.Fields{"cert": certFile, "key": keyFile}).Error("Invalid client certificate or key")
+		return nil, fmt.Errorf("Invalid client certificate %s or key %s: %s", certFile, keyFile, err.Error())
+	}
 
-		certFile := filepath.Join(certPath, "cert.pem")
-		if _, err := os.Stat(certFile); os.IsNotExist(err) {
-			log.WithField("file", certFile).Error("Cannot open file")
-			log.Error("Incorrect usage, please set correct cert files")
-			return nil, host, errors.New("Unable to connect to docker host")
+	// Without CA, the client does not verify the certificate of the host
+	if tlsVerify {
+		caPEM, err := readCertFile(caFile)
+		if err != nil {
+			return nil, err
 		}
-
-		keyFile := filepath.Join(certPath, "key.pem")
-		if _, err := os.Stat(keyFile); os.IsNotExist(err) {
-			log.WithField("file", keyFile).Error("Cannot open file")
-			log.Error("Incorrect usage, please set correct cert files")
-			return nil, host, errors.New("Unable to connect to docker host")
+		if !x509.NewCertPool().AppendCertsFromPEM(caPEM) {
+			log.WithField("file", caFile).Error("No valid CA certificate found")
+			return nil, fmt.Errorf("No valid PEM encoded CA certificate found in %s", caFile)
 		}
-
-		cert, _ := tls.LoadX509KeyPair(certFile, keyFile)
-		pemCerts, _ := ioutil.ReadFile(caFile)
-
-		tlsConfig.RootCAs = x509.NewCertPool()
-		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
-		tlsConfig.Certificates = []tls.Certificate{cert}
-		tlsConfig.RootCAs.AppendCertsFromPEM(pemCerts)
-	}
-	var dockerClient *dockerapi.Client
-	var err error
-	if certPath == "" {
-		dockerClient, err = dockerapi.NewClient(host)
 	} else {
-		dockerClient, err = dockerapi.NewClient(host)
+		log.WithField("host", host).Warn("TLS verification is disabled, the certificate of the Docker host is not checked")
+		caFile = ""
 	}
+
+	dockerClient, err := dockerapi.NewTLSClient(host, certFile, keyFile, caFile)
 	if err != nil {
-		log.Error("Unable to connect to docker host")
-		return nil, host, err
+		log.WithError(err).WithField("host", host).Error("Unable to create TLS client of docker host")
+		return nil, err
 	}
+	return dockerClient, nil
+}
+
+func readCertFile(path string) ([]byte, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		log.WithError(err).WithField("file", path).Error("Cannot open file")
+		log.Error("Incorrect usage, please set correct cert files")
+		return nil, fmt.Errorf("Cannot read certificate file %s: %s", path, err.Error())
+	}
+	return content, nil
+}
+
+// PingDockerClient checks that the Docker host answers
+func PingDockerClient(dockerClient *dockerapi.Client, host string) error {
 	env, err := dockerClient.Docker.Version()
 	if err != nil {
-		log.WithError(err).Error("Unable to ping docker host")
-		return nil, host, err
+		if strings.Contains(err.Error(), "x509") || strings.Contains(err.Error(), "tls") {
+			log.WithError(err).WithField("host", host).Error("TLS handshake with docker host failed, check the certificates or --tls-verify")
+		} else {
+			log.WithError(err).WithField("host", host).Error("Unable to ping docker host")
+		}
+		return err
 	}
 	log.Info("Connected to Docker Host " + host)
 	log.Debug("Docker Version: " + env.Get("Version"))
 	log.Debug("Git Commit:" + env.Get("GitCommit"))
 	log.Debug("Go Version:" + env.Get("GoVersion"))
-
-	return dockerClient, host, err
+	return nil
 }
 
 func Contains(slice []string, item string) bool {
@@ -182,17 +174,3 @@ func IndexOf(slice []string, item string) (ind int, ok bool) {
 	}
 	return ind, false
 }
-
-func HandleError(message string, err error, c *gin.Context) map[string]string {
-	log.Warn("*******************************************************************************")
-	log.Warn("RemoteAddr :\t" + c.Request.RemoteAddr)
-	log.Warn("RequestURI :\t" + c.Request.RequestURI)
-	log.Warn("Message :\t" + message)
-	log.Warn("Error :\t" + err.Error())
-	log.Warn("*******************************************************************************")
-
-	return map[string]string{
-		"message": message,
-		"details": err.Error(),
-	}
-}
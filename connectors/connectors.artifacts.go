@@ -0,0 +1,138 @@
+package connectors
+
+import (
+	"archive/tar"
+	"io"
+	"io/ioutil"
+	"mime"
+	"path"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/soprasteria/intools-engine/artifacts"
+	"github.com/soprasteria/intools-engine/backends"
+	"github.com/soprasteria/intools-engine/executors"
+)
+
+var (
+	// ArtifactStore keeps the files collected after the executions, collection is disabled when nil
+	ArtifactStore  artifacts.Store
+	ArtifactLimits = artifacts.Limits{MaxFileSize: 10 << 20, MaxTotalSize: 50 << 20}
+)
+
+// ArtifactsConfig declares the directory of the container where the connector writes its artifacts
+type ArtifactsConfig struct {
+	Path string `json:"path"`
+}
+
+// collectArtifacts copies the files of the artifacts directory out of the stopped instance into the store.
+// Artifacts are optional : failures are logged and do not fail the execution.
+func collectArtifacts(connector *Connector, executor *executors.Executor, backend backends.Backend, instance *backends.Instance) {
+	if connector.Artifacts == nil || connector.Artifacts.Path == "" {
+		return
+	}
+	if ArtifactStore == nil {
+		log.Warnf("Connector %s declares artifacts but no artifact store is configured", connector.Id())
+		return
+	}
+	copier, ok := backend.(backends.ArtifactsCopier)
+	if !ok {
+		log.Warnf("Backend cannot collect artifacts of connector %s", connector.Id())
+		return
+	}
+
+	reader, writer := io.Pipe()
+	go func() {
+		writer.CloseWithError(copier.CopyArtifacts(instance, connector.Artifacts.Path, writer))
+	}()
+	defer reader.Close()
+
+	archive := tar.NewReader(reader)
+	var total int64
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			log.WithError(err).Warnf("Cannot read artifacts of connector %s", connector.Id())
+			break
+		}
+		if header.Typeflag != tar.TypeReg && header.Typeflag != tar.TypeRegA {
+			continue
+		}
+
+		name := getArtifactName(header.Name)
+		fields := log.Fields{"artifact": name, "size": header.Size, "execution": executor.Id}
+		if header.Size > ArtifactLimits.MaxFileSize {
+			log.WithFields(fields).Warn("Artifact is too large, skipping it")
+			continue
+		}
+		if total+header.Size > ArtifactLimits.MaxTotalSize {
+			log.WithFields(fields).Warn("Artifacts of execution are too large, skipping the remaining ones")
+			break
+		}
+
+		content, err := ioutil.ReadAll(io.LimitReader(archive, header.Size))
+		if err != nil {
+			log.WithError(err).WithFields(fields).Warn("Cannot read artifact")
+			break
+		}
+		err = ArtifactStore.Save(connector.Group, connector.Name, executor.Id, name, content)
+		if err != nil {
+			log.WithError(err).WithFields(fields).Warn("Cannot save artifact")
+			continue
+		}
+		total += header.Size
+		executor.Artifacts = append(executor.Artifacts, executors.Artifact{
+			Name:        name,
+			Size:        header.Size,
+			ContentType: getArtifactContentType(name),
+		})
+		log.WithFields(fields).Debug("Artifact collected")
+	}
+}
+
+// getArtifactName flattens the path of a file in the archive of the artifacts directory
+func getArtifactName(name string) string {
+	parts := strings.SplitN(strings.TrimPrefix(path.Clean(name), "/"), "/", 2)
+	if len(parts) == 2 {
+		name = parts[1]
+	}
+	return strings.Replace(name, "/", "_", -1)
+}
+
+func getArtifactContentType(name string) string {
+	contentType := mime.TypeByExtension(path.Ext(name))
+	if contentType == "" {
+		return "application/octet-stream"
+	}
+	return contentType
+}
+
+// RemoveArtifacts deletes the artifacts of an execution from the store
+func RemoveArtifacts(c *Connector, executor *executors.Executor) {
+	if ArtifactStore == nil || len(executor.Artifacts) == 0 {
+		return
+	}
+	names := make([]string, len(executor.Artifacts))
+	for i, a := range executor.Artifacts {
+		names[i] = a.Name
+	}
+	err := ArtifactStore.Delete(c.Group, c.Name, executor.Id, names)
+	if err != nil {
+		log.WithError(err).Warnf("Cannot remove artifacts of execution %s", executor.Id)
+	}
+}
+
+// GetArtifact returns the content of an artifact of an execution of the connector
+func GetArtifact(c *Connector, execution string, name string) ([]byte, string, error) {
+	if ArtifactStore == nil {
+		return nil, "", artifacts.ErrNotFound
+	}
+	content, err := ArtifactStore.Get(c.Group, c.Name, execution, name)
+	if err != nil {
+		return nil, "", err
+	}
+	return content, getArtifactContentType(name), nil
+}
@@ -0,0 +1,394 @@
+package connectors
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/soprasteria/intools-engine/common/store"
+	"github.com/soprasteria/intools-engine/executors"
+)
+
+// FileConnectorRepository is a MemoryConnectorRepository which writes each change to its own file, so that a
+// change costs the size of what changed. The files follow the layout of the Redis keys :
+//
+//	groups/<group>/retention.json
+//	groups/<group>/defaults.json
+//	groups/<group>/connectors/<connector>/connector.json
+//	groups/<group>/connectors/<connector>/last.json
+//	groups/<group>/connectors/<connector>/result.json
+//	groups/<group>/connectors/<connector>/executions/<id>.json
+//	groups/<group>/connectors/<connector>/versions/<version>.json
+//	groups/<group>/connectors/<connector>/series/<name>.jsonl, one point per line
+//
+// Everything is also kept in memory for the lifetime of the engine, which reads back the whole store when it starts :
+// the executions of the history with their stdout and stderr, the points of the series and the versions. Their
+// memory is bounded by the retention of the connectors only.
+type FileConnectorRepository struct {
+	*MemoryConnectorRepository
+	path string
+	// mutex keeps the files in the order of the changes
+	mutex sync.Mutex
+}
+
+// NewFileConnectorRepository loads the files of the store under path, when they exist
+func NewFileConnectorRepository(path string) (*FileConnectorRepository, error) {
+	r := &FileConnectorRepository{MemoryConnectorRepository: NewMemoryConnectorRepository(), path: path}
+	err := r.load()
+	if err != nil {
+		log.WithError(err).WithField("path", path).Error("Unable to load connectors store")
+		return nil, err
+	}
+	log.WithField("path", path).Infof("Loaded %d connectors from store", len(r.data.Connectors))
+	return r, nil
+}
+
+func (r *FileConnectorRepository) groupDir(group string) string {
+	return filepath.Join(r.path, "groups", store.Escape(group))
+}
+
+func (r *FileConnectorRepository) connectorDir(group string, connector string) string {
+	return filepath.Join(r.groupDir(group), "connectors", store.Escape(connector))
+}
+
+func (r *FileConnectorRepository) dir(c *Connector) string {
+	return r.connectorDir(c.Group, c.Name)
+}
+
+func (r *FileConnectorRepository) executionFile(c *Connector, id string) string {
+	return filepath.Join(r.dir(c), "executions", store.Escape(id)+".json")
+}
+
+func (r *FileConnectorRepository) load() error {
+	data := r.data
+	groups, err := store.ListDir(filepath.Join(r.path, "groups"))
+	if err != nil {
+		return err
+	}
+	for _, group := range groups {
+		var retention *Retention
+		err = store.LoadJSON(filepath.Join(r.groupDir(group), "retention.json"), &retention)
+		if err != nil {
+			return err
+		}
+		if retention != nil {
+			data.Retentions[group] = retention
+		}
+		var defaults *Defaults
+		err = store.LoadJSON(filepath.Join(r.groupDir(group), "defaults.json"), &defaults)
+		if err != nil {
+			return err
+		}
+		if defaults != nil {
+			data.Defaults[group] = defaults
+		}
+
+		names, err := store.ListDir(filepath.Join(r.groupDir(group), "connectors"))
+		if err != nil {
+			return err
+		}
+		saved := map[string]time.Time{}
+		for _, name := range names {
+			modTime, err := r.loadConnector(group, name)
+			if err != nil {
+				return err
+			}
+			if !modTime.IsZero() {
+				saved[name] = modTime
+				data.Groups[group] = append(data.Groups[group], name)
+			}
+		}
+		// Most recently saved first
+		sort.SliceStable(data.Groups[group], func(i, j int) bool {
+			return saved[data.Groups[group][i]].After(saved[data.Groups[group][j]])
+		})
+	}
+	return nil
+}
+
+// loadConnector loads the files of a connector, and returns the time its configuration was saved, zero when
+// only its data is left
+func (r *FileConnectorRepository) loadConnector(group string, name string) (time.Time, error) {
+	data := r.data
+	dir := r.connectorDir(group, name)
+	id := group + ":" + name
+
+	var last *executors.Executor
+	err := store.LoadJSON(filepath.Join(dir, "last.json"), &last)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if last != nil {
+		data.Last[id] = last
+	}
+	var result *map[string]interface{}
+	err = store.LoadJSON(filepath.Join(dir, "result.json"), &result)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if result != nil {
+		data.Results[id] = result
+	}
+
+	err = loadFiles(filepath.Join(dir, "executions"), func() interface{} { return &executors.Executor{} }, func(v interface{}) {
+		data.Executions[id] = append(data.Executions[id], v.(*executors.Executor))
+	})
+	if err != nil {
+		return time.Time{}, err
+	}
+	executions := data.Executions[id]
+	sort.SliceStable(executions, func(i, j int) bool { return executions[i].CreatedAt.Before(executions[j].CreatedAt) })
+
+	err = loadFiles(filepath.Join(dir, "versions"), func() interface{} { return &ConfigVersion{} }, func(v interface{}) {
+		data.Versions[id] = append(data.Versions[id], v.(*ConfigVersion))
+	})
+	if err != nil {
+		return time.Time{}, err
+	}
+	versions := data.Versions[id]
+	sort.Slice(versions, func(i, j int) bool { return versions[i].Version < versions[j].Version })
+
+	series, err := store.ListDir(filepath.Join(dir, "series"))
+	if err != nil {
+		return time.Time{}, err
+	}
+	for _, file := range series {
+		if !strings.HasSuffix(file, ".jsonl") {
+			continue
+		}
+		points := []Point{}
+		err = store.LoadJSONLines(filepath.Join(dir, "series", store.Escape(file)), func(line []byte) error {
+			point := Point{}
+			err := json.Unmarshal(line, &point)
+			points = append(points, point)
+			return err
+		})
+		if err != nil {
+			return time.Time{}, err
+		}
+		sort.SliceStable(points, func(i, j int) bool { return points[i].Time.Before(points[j].Time) })
+		if data.Series[id] == nil {
+			data.Series[id] = map[string][]Point{}
+		}
+		data.Series[id][strings.TrimSuffix(file, ".jsonl")] = points
+	}
+
+	file := filepath.Join(dir, "connector.json")
+	info, err := os.Stat(file)
+	if os.IsNotExist(err) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	c := &Connector{}
+	err = store.LoadJSON(file, c)
+	if err != nil {
+		return time.Time{}, err
+	}
+	data.Connectors[id] = c
+	return info.ModTime(), nil
+}
+
+// loadFiles decodes each JSON file of a directory into a new value, and gives it to add
+func loadFiles(dir string, value func() interface{}, add func(v interface{})) error {
+	files, err := store.ListDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		if !strings.HasSuffix(file, ".json") {
+			continue
+		}
+		v := value()
+		err = store.LoadJSON(filepath.Join(dir, store.Escape(file)), v)
+		if err != nil {
+			return err
+		}
+		add(v)
+	}
+	return nil
+}
+
+func (r *FileConnectorRepository) SaveConnector(c *Connector) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	err := r.MemoryConnectorRepository.SaveConnector(c)
+	if err != nil {
+		return err
+	}
+	return store.SaveJSON(filepath.Join(r.dir(c), "connector.json"), c)
+}
+
+func (r *FileConnectorRepository) SaveConnectorIf(c *Connector, etag string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	err := r.MemoryConnectorRepository.SaveConnectorIf(c, etag)
+	if err != nil {
+		return err
+	}
+	return store.SaveJSON(filepath.Join(r.dir(c), "connector.json"), c)
+}
+
+func (r *FileConnectorRepository) RemoveConfigIf(c *Connector, etag string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	err := r.MemoryConnectorRepository.RemoveConfigIf(c, etag)
+	if err != nil {
+		return err
+	}
+	return store.Remove(filepath.Join(r.dir(c), "connector.json"))
+}
+
+func (r *FileConnectorRepository) RemoveConnector(c *Connector) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	err := r.MemoryConnectorRepository.RemoveConnector(c)
+	if err != nil {
+		return err
+	}
+	return store.Remove(r.dir(c))
+}
+
+func (r *FileConnectorRepository) SaveConnectorVersion(c *Connector, version *ConfigVersion, etag *string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	err := r.MemoryConnectorRepository.SaveConnectorVersion(c, version, etag)
+	if err != nil {
+		return err
+	}
+	err = store.SaveJSON(filepath.Join(r.dir(c), "versions", strconv.Itoa(version.Version)+".json"), version)
+	if err != nil {
+		return err
+	}
+	return store.SaveJSON(filepath.Join(r.dir(c), "connector.json"), c)
+}
+
+func (r *FileConnectorRepository) SaveExecutor(c *Connector, exec *executors.Executor) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	err := r.MemoryConnectorRepository.SaveExecutor(c, exec)
+	if err != nil {
+		return err
+	}
+	err = store.SaveJSON(r.executionFile(c, exec.Id), exec)
+	if err != nil {
+		return err
+	}
+	err = store.SaveJSON(filepath.Join(r.dir(c), "last.json"), exec)
+	if err != nil || !exec.Valid {
+		return err
+	}
+	return store.SaveJSON(filepath.Join(r.dir(c), "result.json"), exec.JsonStdout)
+}
+
+func (r *FileConnectorRepository) RemoveExecutions(c *Connector, ids []string) ([]*executors.Executor, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	removed, err := r.MemoryConnectorRepository.RemoveExecutions(c, ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, exec := range removed {
+		err = store.Remove(r.executionFile(c, exec.Id))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return removed, nil
+}
+
+func (r *FileConnectorRepository) AddPoint(c *Connector, name string, point Point) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	err := r.MemoryConnectorRepository.AddPoint(c, name, point)
+	if err != nil {
+		return err
+	}
+	return store.AppendJSON(filepath.Join(r.dir(c), "series", store.Escape(name)+".jsonl"), point)
+}
+
+func (r *FileConnectorRepository) SaveLastResult(c *Connector, result *map[string]interface{}) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	err := r.MemoryConnectorRepository.SaveLastResult(c, result)
+	if err != nil {
+		return err
+	}
+	return store.SaveJSON(filepath.Join(r.dir(c), "result.json"), result)
+}
+
+func (r *FileConnectorRepository) RemoveLastResult(c *Connector) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	err := r.MemoryConnectorRepository.RemoveLastResult(c)
+	if err != nil {
+		return err
+	}
+	return store.Remove(filepath.Join(r.dir(c), "result.json"))
+}
+
+func (r *FileConnectorRepository) SaveGroupRetention(group string, retention *Retention) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	err := r.MemoryConnectorRepository.SaveGroupRetention(group, retention)
+	if err != nil {
+		return err
+	}
+	file := filepath.Join(r.groupDir(group), "retention.json")
+	if retention == nil {
+		return store.Remove(file)
+	}
+	return store.SaveJSON(file, retention)
+}
+
+func (r *FileConnectorRepository) SaveGroupDefaults(group string, defaults *Defaults) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	err := r.MemoryConnectorRepository.SaveGroupDefaults(group, defaults)
+	if err != nil {
+		return err
+	}
+	file := filepath.Join(r.groupDir(group), "defaults.json")
+	if defaults == nil {
+		return store.Remove(file)
+	}
+	return store.SaveJSON(file, defaults)
+}
+
+// RemoveOrphans removes the directories of the connectors whose configuration is gone
+func (r *FileConnectorRepository) RemoveOrphans() (int, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	removed, err := r.MemoryConnectorRepository.RemoveOrphans()
+	if err != nil {
+		return 0, err
+	}
+	groups, err := store.ListDir(filepath.Join(r.path, "groups"))
+	if err != nil {
+		return 0, err
+	}
+	for _, group := range groups {
+		names, err := store.ListDir(filepath.Join(r.groupDir(group), "connectors"))
+		if err != nil {
+			return 0, err
+		}
+		for _, name := range names {
+			_, err = os.Stat(filepath.Join(r.connectorDir(group, name), "connector.json"))
+			if !os.IsNotExist(err) {
+				continue
+			}
+			err = store.Remove(r.connectorDir(group, name))
+			if err != nil {
+				return 0, err
+			}
+		}
+	}
+	return removed, nil
+}
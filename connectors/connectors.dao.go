@@ -1,72 +1,92 @@
 package connectors
 
 import (
-	"encoding/json"
-
 	log "github.com/Sirupsen/logrus"
 	"github.com/soprasteria/intools-engine/executors"
 )
 
 func SaveExecutor(c *Connector, exec *executors.Executor) {
-	err := RedisSaveExecutor(c, exec)
+	err := Repository.SaveExecutor(c, exec)
 	if err != nil {
-		log.WithError(err).Error("Error while saving to Redis")
+		log.WithError(err).Error("Error while saving to store")
 	}
 }
 
 func SaveConnector(c *Connector) {
-	err := RedisSaveConnector(c)
+	err := Repository.SaveConnector(c)
+	if err != nil {
+		log.WithError(err).Error("Error while saving to store")
+	}
+}
+
+func RemoveConnector(c *Connector) error {
+	err := RemoveExecutions(c)
+	if err != nil {
+		return err
+	}
+	err = Repository.RemoveConnector(c)
 	if err != nil {
-		log.WithError(err).Error("Error while saving to Redis")
+		log.WithError(err).Error("Error while removing from store")
 	}
+	return err
 }
 
-func RemoveConnector(c *Connector) {
-	err := RedisRemoveConnector(c)
+// RemoveConnectorIf removes the connector when its stored configuration has the ETag etag, and returns ErrModified
+// otherwise
+func RemoveConnectorIf(c *Connector, etag string) error {
+	err := Repository.RemoveConfigIf(c, etag)
 	if err != nil {
-		log.WithError(err).Error("Error while removing from Redis")
+		return err
 	}
+	return RemoveConnector(c)
 }
 
 func GetLastConnectorExecutor(c *Connector) *executors.Executor {
-	sExecutor, err := RedisGetLastExecutor(c)
+	executor, err := Repository.GetLastExecutor(c)
 	if err != nil {
-		log.WithError(err).Errorf("Cannot load last executor %s:%s from Redis", c.Group, c.Name)
+		log.WithError(err).Errorf("Cannot load last executor %s:%s", c.Group, c.Name)
 		return nil
 	}
-	executor := &executors.Executor{}
-	err = json.Unmarshal([]byte(sExecutor), executor)
+	return executor
+}
+
+// GetLastConnectorResult returns the result of the last valid execution
+func GetLastConnectorResult(c *Connector) *map[string]interface{} {
+	result, err := Repository.GetLastResult(c)
 	if err != nil {
-		log.WithError(err).Errorf("Cannot parse last executor %s:%s", c.Group, c.Name)
-		log.Error(err.Error())
+		log.WithError(err).Errorf("Cannot load last result %s:%s", c.Group, c.Name)
 		return nil
 	}
-	return executor
+	return result
 }
 
 func GetConnector(group string, connector string) (*Connector, error) {
-	conn, err := RedisGetConnector(group, connector)
+	conn, err := Repository.GetConnector(group, connector)
 	if err != nil {
-		log.WithError(err).Errorf("Error while loading %s:%s to Redis", group, connector)
+		log.WithError(err).Errorf("Error while loading %s:%s", group, connector)
 		return nil, err
 	}
 	return conn, nil
 }
 
+// GetConnectorNames returns the names of the connectors of the group
+func GetConnectorNames(group string) ([]string, error) {
+	names, err := Repository.GetConnectors(group)
+	if err != nil {
+		log.WithError(err).Errorf("Error while getting connectors for group %s", group)
+		return nil, err
+	}
+	return names, nil
+}
+
+// GetConnectors returns the connectors of the group, without those which cannot be loaded
 func GetConnectors(group string) []Connector {
-	ret, err := RedisGetConnectors(group)
+	connectors, loadErrors, err := LoadConnectors(group)
 	if err != nil {
-		log.WithError(err).Errorf("Error while getting connectors for group %s from Redis", group)
 		return nil
 	}
-	connectors := make([]Connector, len(ret))
-	for i, c := range ret {
-		conn, err := GetConnector(group, c)
-		if err != nil {
-			log.Warnf("Unable to load %s:%s", group, c)
-		} else {
-			connectors[i] = *conn
-		}
+	for _, loadError := range loadErrors {
+		log.WithField("error", loadError.Error).Warnf("Unable to load %s:%s", group, loadError.Name)
 	}
 	return connectors
 }
@@ -0,0 +1,518 @@
+package connectors
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/soprasteria/intools-engine/executors"
+)
+
+// memoryConnectorData is the content of a MemoryConnectorRepository
+type memoryConnectorData struct {
+	// Names of the connectors of each group, most recently saved first
+	Groups     map[string][]string
+	Connectors map[string]*Connector
+	Last       map[string]*executors.Executor
+	Results    map[string]*map[string]interface{}
+	// Executions of each connector, oldest first
+	Executions map[string][]*executors.Executor
+	// Points of each series, by connector id and series name, oldest first
+	Series map[string]map[string][]Point
+	// Retention of the connectors of each group
+	Retentions map[string]*Retention
+	// Defaults of the connectors of each group
+	Defaults map[string]*Defaults
+	// Versions of the configuration of each connector, oldest first
+	Versions map[string][]*ConfigVersion
+	// Last version number allocated to each connector
+	Counters map[string]int
+	// Changes of each group
+	GroupVersions map[string]int64
+}
+
+func newMemoryConnectorData() *memoryConnectorData {
+	return &memoryConnectorData{
+		Groups:     map[string][]string{},
+		Connectors: map[string]*Connector{},
+		Last:       map[string]*executors.Executor{},
+		Results:    map[string]*map[string]interface{}{},
+		Executions: map[string][]*executors.Executor{},
+		Series:     map[string]map[string][]Point{},
+		Retentions: map[string]*Retention{},
+		Defaults:   map[string]*Defaults{},
+		Versions:   map[string][]*ConfigVersion{},
+		Counters:   map[string]int{},
+
+		GroupVersions: map[string]int64{},
+	}
+}
+
+// MemoryConnectorRepository keeps everything in the memory of the engine, it is lost when the engine stops
+type MemoryConnectorRepository struct {
+	mutex sync.RWMutex
+	data  *memoryConnectorData
+	// epoch starts the versions of the groups, so that they are not reused once the engine restarts
+	epoch int64
+}
+
+func NewMemoryConnectorRepository() *MemoryConnectorRepository {
+	return &MemoryConnectorRepository{data: newMemoryConnectorData(), epoch: time.Now().UnixNano()}
+}
+
+func copyConnector(c *Connector) *Connector {
+	copied := *c
+	return &copied
+}
+
+func copyExecutor(exec *executors.Executor) *executors.Executor {
+	copied := *exec
+	return &copied
+}
+
+func (r *MemoryConnectorRepository) GetConnectors(group string) ([]string, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return append([]string{}, r.data.Groups[group]...), nil
+}
+
+func (r *MemoryConnectorRepository) GetConnector(group string, connector string) (*Connector, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	c, ok := r.data.Connectors[group+":"+connector]
+	if !ok {
+		return nil, errors.New("Unable to load connectors " + group + "/" + connector + " -> " + ErrNotFound.Error())
+	}
+	return copyConnector(c), nil
+}
+
+func (r *MemoryConnectorRepository) ConnectorExists(group string, connector string) (bool, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	_, ok := r.data.Connectors[group+":"+connector]
+	return ok, nil
+}
+
+func removeName(names []string, name string) []string {
+	kept := make([]string, 0, len(names))
+	for _, n := range names {
+		if n != name {
+			kept = append(kept, n)
+		}
+	}
+	return kept
+}
+
+func (r *MemoryConnectorRepository) SaveConnector(c *Connector) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.saveConnector(c)
+	return nil
+}
+
+func (r *MemoryConnectorRepository) saveConnector(c *Connector) {
+	r.data.Groups[c.Group] = append([]string{c.Name}, removeName(r.data.Groups[c.Group], c.Name)...)
+	r.data.Connectors[c.Id()] = copyConnector(c)
+	r.data.GroupVersions[c.Group]++
+}
+
+// checkETag returns ErrModified when the stored configuration of the connector does not have the ETag etag, empty
+// when it must not exist
+func (r *MemoryConnectorRepository) checkETag(c *Connector, etag string) error {
+	stored, ok := r.data.Connectors[c.Id()]
+	if ok && stored.ETag() == etag || !ok && etag == "" {
+		return nil
+	}
+	return ErrModified
+}
+
+func (r *MemoryConnectorRepository) SaveConnectorIf(c *Connector, etag string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	err := r.checkETag(c, etag)
+	if err != nil {
+		return err
+	}
+	r.saveConnector(c)
+	return nil
+}
+
+func (r *MemoryConnectorRepository) RemoveConfigIf(c *Connector, etag string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	err := r.checkETag(c, etag)
+	if err != nil {
+		return err
+	}
+	r.data.Groups[c.Group] = removeName(r.data.Groups[c.Group], c.Name)
+	if len(r.data.Groups[c.Group]) == 0 {
+		delete(r.data.Groups, c.Group)
+	}
+	delete(r.data.Connectors, c.Id())
+	r.data.GroupVersions[c.Group]++
+	return nil
+}
+
+func (r *MemoryConnectorRepository) RemoveConnector(c *Connector) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.data.Groups[c.Group] = removeName(r.data.Groups[c.Group], c.Name)
+	if len(r.data.Groups[c.Group]) == 0 {
+		delete(r.data.Groups, c.Group)
+	}
+	delete(r.data.Connectors, c.Id())
+	r.data.GroupVersions[c.Group]++
+	delete(r.data.Last, c.Id())
+	delete(r.data.Results, c.Id())
+	delete(r.data.Executions, c.Id())
+	delete(r.data.Series, c.Id())
+	delete(r.data.Versions, c.Id())
+	delete(r.data.Counters, c.Id())
+	return nil
+}
+
+func copyConfigVersion(version *ConfigVersion) *ConfigVersion {
+	copied := *version
+	copied.Connector = copyConnector(version.Connector)
+	return &copied
+}
+
+func (r *MemoryConnectorRepository) SaveConnectorVersion(c *Connector, version *ConfigVersion, etag *string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if etag != nil {
+		err := r.checkETag(c, *etag)
+		if err != nil {
+			return err
+		}
+	}
+	last := r.data.Counters[c.Id()]
+	if versions := r.data.Versions[c.Id()]; len(versions) > 0 && versions[len(versions)-1].Version > last {
+		last = versions[len(versions)-1].Version
+	}
+	setVersion(c, version, last+1)
+	r.data.Counters[c.Id()] = c.Version
+	r.saveConnector(c)
+	r.data.Versions[c.Id()] = append(r.data.Versions[c.Id()], copyConfigVersion(version))
+	return nil
+}
+
+func (r *MemoryConnectorRepository) GetLastConfigVersion(c *Connector) (*ConfigVersion, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	versions := r.data.Versions[c.Id()]
+	if len(versions) == 0 {
+		return nil, ErrNotFound
+	}
+	return copyConfigVersion(versions[len(versions)-1]), nil
+}
+
+func (r *MemoryConnectorRepository) GetConfigVersions(c *Connector) ([]*ConfigVersion, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	stored := r.data.Versions[c.Id()]
+	versions := make([]*ConfigVersion, 0, len(stored))
+	for i := len(stored) - 1; i >= 0; i-- {
+		versions = append(versions, copyConfigVersion(stored[i]))
+	}
+	return versions, nil
+}
+
+func (r *MemoryConnectorRepository) GetConfigVersion(c *Connector, version int) (*ConfigVersion, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	for _, v := range r.data.Versions[c.Id()] {
+		if v.Version == version {
+			return copyConfigVersion(v), nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *MemoryConnectorRepository) SaveExecutor(c *Connector, exec *executors.Executor) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	copied := copyExecutor(exec)
+	r.data.Last[c.Id()] = copied
+	if exec.Valid {
+		r.data.Results[c.Id()] = exec.JsonStdout
+	}
+
+	executions := r.data.Executions[c.Id()]
+	for i, e := range executions {
+		if e.Id == exec.Id {
+			executions[i] = copied
+			return nil
+		}
+	}
+	index := sort.Search(len(executions), func(i int) bool {
+		return executions[i].CreatedAt.After(exec.CreatedAt)
+	})
+	executions = append(executions, nil)
+	copy(executions[index+1:], executions[index:])
+	executions[index] = copied
+	r.data.Executions[c.Id()] = executions
+	return nil
+}
+
+func (r *MemoryConnectorRepository) GetLastExecutor(c *Connector) (*executors.Executor, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	exec, ok := r.data.Last[c.Id()]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return copyExecutor(exec), nil
+}
+
+func (r *MemoryConnectorRepository) GetLastResult(c *Connector) (*map[string]interface{}, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	result, ok := r.data.Results[c.Id()]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return result, nil
+}
+
+func (r *MemoryConnectorRepository) GetExecution(c *Connector, id string) (*executors.Executor, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	for _, e := range r.data.Executions[c.Id()] {
+		if e.Id == id {
+			return copyExecutor(e), nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *MemoryConnectorRepository) GetExecutions(c *Connector, limit int64, before string) ([]*executors.Executor, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	executions := r.data.Executions[c.Id()]
+	end := len(executions)
+	if before != "" {
+		end = -1
+		for i, e := range executions {
+			if e.Id == before {
+				end = i
+				break
+			}
+		}
+		if end < 0 {
+			return nil, ErrNotFound
+		}
+	}
+	page := []*executors.Executor{}
+	for i := end - 1; i >= 0 && int64(len(page)) < limit; i-- {
+		page = append(page, copyExecutor(executions[i]))
+	}
+	return page, nil
+}
+
+func (r *MemoryConnectorRepository) GetExpiredExecutions(c *Connector, size int64, maxAge time.Duration) ([]string, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	executions := r.data.Executions[c.Id()]
+	cutoff := time.Now().Add(-maxAge)
+	ids := []string{}
+	for i, e := range executions {
+		if (size > 0 && int64(len(executions)-i) > size) || (maxAge > 0 && e.CreatedAt.Before(cutoff)) {
+			ids = append(ids, e.Id)
+		}
+	}
+	return ids, nil
+}
+
+func (r *MemoryConnectorRepository) GetExecutionIds(c *Connector) ([]string, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	ids := []string{}
+	for _, e := range r.data.Executions[c.Id()] {
+		ids = append(ids, e.Id)
+	}
+	return ids, nil
+}
+
+func (r *MemoryConnectorRepository) RemoveExecutions(c *Connector, ids []string) ([]*executors.Executor, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	removed := map[string]bool{}
+	for _, id := range ids {
+		removed[id] = true
+	}
+	kept := []*executors.Executor{}
+	executions := []*executors.Executor{}
+	for _, e := range r.data.Executions[c.Id()] {
+		if removed[e.Id] {
+			executions = append(executions, e)
+		} else {
+			kept = append(kept, e)
+		}
+	}
+	r.data.Executions[c.Id()] = kept
+	return executions, nil
+}
+
+func (r *MemoryConnectorRepository) AddPoint(c *Connector, name string, point Point) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	series, ok := r.data.Series[c.Id()]
+	if !ok {
+		series = map[string][]Point{}
+		r.data.Series[c.Id()] = series
+	}
+	points := series[name]
+	index := sort.Search(len(points), func(i int) bool {
+		return points[i].Time.After(point.Time)
+	})
+	points = append(points, Point{})
+	copy(points[index+1:], points[index:])
+	points[index] = point
+	series[name] = points
+	return nil
+}
+
+func (r *MemoryConnectorRepository) GetPoints(c *Connector, name string, from time.Time, to time.Time) ([]Point, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	points := []Point{}
+	for _, p := range r.data.Series[c.Id()][name] {
+		if !p.Time.Before(from) && !p.Time.After(to) {
+			points = append(points, p)
+		}
+	}
+	return points, nil
+}
+
+func (r *MemoryConnectorRepository) SaveLastResult(c *Connector, result *map[string]interface{}) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.data.Results[c.Id()] = result
+	return nil
+}
+
+func (r *MemoryConnectorRepository) RemoveLastResult(c *Connector) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	delete(r.data.Results, c.Id())
+	return nil
+}
+
+func (r *MemoryConnectorRepository) GetGroupRetention(group string) (*Retention, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	retention, ok := r.data.Retentions[group]
+	if !ok {
+		return nil, nil
+	}
+	copied := *retention
+	return &copied, nil
+}
+
+func (r *MemoryConnectorRepository) SaveGroupRetention(group string, retention *Retention) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if retention == nil {
+		delete(r.data.Retentions, group)
+	} else {
+		copied := *retention
+		r.data.Retentions[group] = &copied
+	}
+	r.data.GroupVersions[group]++
+	return nil
+}
+
+func (r *MemoryConnectorRepository) GetGroupDefaults(group string) (*Defaults, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	defaults, ok := r.data.Defaults[group]
+	if !ok {
+		return nil, nil
+	}
+	return copyDefaults(defaults), nil
+}
+
+func (r *MemoryConnectorRepository) SaveGroupDefaults(group string, defaults *Defaults) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if defaults == nil {
+		delete(r.data.Defaults, group)
+	} else {
+		r.data.Defaults[group] = copyDefaults(defaults)
+	}
+	r.data.GroupVersions[group]++
+	return nil
+}
+
+func (r *MemoryConnectorRepository) GetGroupVersion(group string) (int64, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return r.epoch + r.data.GroupVersions[group], nil
+}
+
+func (r *MemoryConnectorRepository) BumpGroupVersion(group string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.data.GroupVersions[group]++
+	return nil
+}
+
+// copyDefaults returns a copy of the defaults sharing nothing with them
+func copyDefaults(d *Defaults) *Defaults {
+	copied := *d
+	copied.Env = append([]string(nil), d.Env...)
+	if d.Resources != nil {
+		resources := *d.Resources
+		copied.Resources = &resources
+	}
+	return &copied
+}
+
+func (r *MemoryConnectorRepository) RemoveOrphans() (int, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	removed := 0
+	orphan := func(id string) bool {
+		_, ok := r.data.Connectors[id]
+		if !ok {
+			removed++
+		}
+		return !ok
+	}
+	for id := range r.data.Last {
+		if orphan(id) {
+			delete(r.data.Last, id)
+		}
+	}
+	for id := range r.data.Results {
+		if orphan(id) {
+			delete(r.data.Results, id)
+		}
+	}
+	for id := range r.data.Executions {
+		if orphan(id) {
+			delete(r.data.Executions, id)
+		}
+	}
+	for id := range r.data.Series {
+		if orphan(id) {
+			delete(r.data.Series, id)
+		}
+	}
+	for id := range r.data.Versions {
+		if orphan(id) {
+			delete(r.data.Versions, id)
+		}
+	}
+	for id := range r.data.Counters {
+		if _, ok := r.data.Connectors[id]; !ok {
+			delete(r.data.Counters, id)
+		}
+	}
+	if removed == 0 {
+		return 0, nil
+	}
+	return removed, nil
+}
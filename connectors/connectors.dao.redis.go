@@ -3,15 +3,20 @@ package connectors
 import (
 	"encoding/json"
 	"errors"
-	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	log "github.com/Sirupsen/logrus"
+	"github.com/soprasteria/intools-engine/common/keys"
 	"github.com/soprasteria/intools-engine/executors"
 	"github.com/soprasteria/intools-engine/intools"
+	"gopkg.in/redis.v3"
 )
 
 func GetRedisConnectorsKey(c *Connector) string {
-	return "intools:groups:" + c.Group + ":connectors"
+	return keys.Connectors(c.Group)
 }
 
 func GetRedisConnectorKey(c *Connector) string {
@@ -19,11 +24,11 @@ func GetRedisConnectorKey(c *Connector) string {
 }
 
 func GetRedisrKey(g string, c string) string {
-	return "intools:groups:" + g + ":connectors:" + c
+	return keys.Connector(g, c)
 }
 
 func GetRedisConnectorConfKey(g string, c string) string {
-	return GetRedisrKey(g, c) + ":conf"
+	return keys.ConnectorConf(g, c)
 }
 
 func RedisGetConnectors(group string) ([]string, error) {
@@ -31,14 +36,13 @@ func RedisGetConnectors(group string) ([]string, error) {
 	if err != nil {
 		return nil, err
 	}
-	defer r.Close()
 
-	key := fmt.Sprintf("intools:groups:%s:connectors", group)
-	len, err := r.LLen(key).Result()
+	names, err := r.SMembers(keys.Connectors(group)).Result()
 	if err != nil {
 		return nil, err
 	}
-	return r.LRange(key, 0, len).Result()
+	sort.Strings(names)
+	return names, nil
 }
 
 func RedisGetConnector(group string, connector string) (*Connector, error) {
@@ -46,7 +50,6 @@ func RedisGetConnector(group string, connector string) (*Connector, error) {
 	if err != nil {
 		return nil, err
 	}
-	defer r.Close()
 	log.Debugf("Loading %s:%s from redis", group, connector)
 	key := GetRedisConnectorConfKey(group, connector)
 	cmd := r.Get(key)
@@ -65,54 +68,244 @@ func RedisGetConnector(group string, connector string) (*Connector, error) {
 	return c, nil
 }
 
+func RedisConnectorExists(group string, connector string) (bool, error) {
+	r, err := intools.Engine.GetRedisClient()
+	if err != nil {
+		return false, err
+	}
+	return r.Exists(GetRedisConnectorConfKey(group, connector)).Result()
+}
+
 func RedisSaveConnector(c *Connector) error {
 	r, err := intools.Engine.GetRedisClient()
 	if err != nil {
 		return err
 	}
-	defer r.Close()
 	log.Debugf("Saving %s to redis", c.Group)
-	multi := r.Multi()
-	defer multi.Close()
-	_, err = multi.Exec(func() error {
-		multi.LRem(GetRedisrKey(c.Group, c.Name), 0, c.Group)
-		multi.LPush(GetRedisrKey(c.Group, c.Name), c.Group)
-		multi.LRem(GetRedisConnectorsKey(c), 0, c.Name)
-		multi.LPush(GetRedisConnectorsKey(c), c.Name)
-		multi.Set(GetRedisConnectorConfKey(c.Group, c.Name), c.GetJSON(), 0)
+	err = intools.RedisTransaction(r, func(tx intools.RedisWrapper) error {
+		tx.SAdd(GetRedisConnectorsKey(c), c.Name)
+		tx.Set(GetRedisConnectorConfKey(c.Group, c.Name), c.GetJSON(), 0)
+		tx.Incr(GetRedisGroupVersionKey(c.Group))
 		return nil
 	})
 	return err
 }
 
+// checkRedisETag returns ErrModified when the stored configuration of a connector does not have the ETag etag,
+// empty when it must not exist
+func checkRedisETag(r intools.RedisWrapper, c *Connector, etag string) error {
+	value, err := r.Get(GetRedisConnectorConfKey(c.Group, c.Name)).Result()
+	if err == redis.Nil {
+		if etag == "" {
+			return nil
+		}
+		return ErrModified
+	}
+	if err != nil {
+		return err
+	}
+	stored := &Connector{}
+	err = json.Unmarshal([]byte(value), stored)
+	if err != nil {
+		return err
+	}
+	if stored.ETag() != etag {
+		return ErrModified
+	}
+	return nil
+}
+
+// checkRedisETagOf checks the ETag of the connector in RedisCheckAndSet
+func checkRedisETagOf(c *Connector, etag string) func(r intools.RedisWrapper) error {
+	return func(r intools.RedisWrapper) error {
+		return checkRedisETag(r, c, etag)
+	}
+}
+
+// RedisSaveConnectorIf saves the connector when its configuration still has the ETag etag, watching its key so that
+// a concurrent write fails the transaction
+func RedisSaveConnectorIf(c *Connector, etag string) error {
+	r, err := intools.Engine.GetRedisClient()
+	if err != nil {
+		return err
+	}
+	log.Debugf("Saving %s:%s to redis if its ETag is %s", c.Group, c.Name, etag)
+	err = intools.RedisCheckAndSet(r, []string{GetRedisConnectorConfKey(c.Group, c.Name)}, checkRedisETagOf(c, etag), func(tx intools.RedisWrapper) error {
+		tx.SAdd(GetRedisConnectorsKey(c), c.Name)
+		tx.Set(GetRedisConnectorConfKey(c.Group, c.Name), c.GetJSON(), 0)
+		tx.Incr(GetRedisGroupVersionKey(c.Group))
+		return nil
+	})
+	if err == intools.ErrKeyModified {
+		return ErrModified
+	}
+	return err
+}
+
+// RedisRemoveConfigIf removes the configuration of the connector when it still has the ETag etag
+func RedisRemoveConfigIf(c *Connector, etag string) error {
+	r, err := intools.Engine.GetRedisClient()
+	if err != nil {
+		return err
+	}
+	log.Debugf("Removing configuration of %s:%s from redis if its ETag is %s", c.Group, c.Name, etag)
+	err = intools.RedisCheckAndSet(r, []string{GetRedisConnectorConfKey(c.Group, c.Name)}, checkRedisETagOf(c, etag), func(tx intools.RedisWrapper) error {
+		tx.Del(GetRedisConnectorConfKey(c.Group, c.Name))
+		tx.SRem(GetRedisConnectorsKey(c), c.Name)
+		tx.Incr(GetRedisGroupVersionKey(c.Group))
+		return nil
+	})
+	if err == intools.ErrKeyModified {
+		return ErrModified
+	}
+	return err
+}
+
 func RedisRemoveConnector(c *Connector) error {
 	r, err := intools.Engine.GetRedisClient()
 	if err != nil {
 		return err
 	}
-	defer r.Close()
 	log.Debugf("Removing %s:%s from redis", c.Group, c.Name)
-	multi := r.Multi()
-	defer multi.Close()
-	_, err = multi.Exec(func() error {
-		multi.Del(GetRedisConnectorConfKey(c.Group, c.Name))
-		multi.Del(GetRedisExecutorKey(c))
-		multi.Del(GetRedisResultKey(c))
-		multi.Del(GetRedisConnectorsKey(c))
-		multi.Del(GetRedisConnectorsKey(c))
-		multi.Del(GetRedisrKey(c.Group, c.Name))
-		multi.Del(GetRedisrKey(c.Group, c.Name))
+	err = intools.RedisTransaction(r, func(tx intools.RedisWrapper) error {
+		tx.Del(GetRedisConnectorConfKey(c.Group, c.Name))
+		tx.Del(GetRedisExecutorKey(c))
+		tx.Del(GetRedisResultKey(c))
+		tx.Del(GetRedisExecutionsKey(c))
+		tx.Del(GetRedisVersionsKey(c))
+		tx.Del(GetRedisVersionCounterKey(c))
+		tx.SRem(GetRedisConnectorsKey(c), c.Name)
+		tx.Incr(GetRedisGroupVersionKey(c.Group))
+		for name := range c.Series {
+			tx.Del(GetRedisSeriesKey(c, name))
+		}
 		return nil
 	})
 	return err
 }
 
+// GetRedisVersionsKey is the sorted set of the versions of the configuration of the connector, scored by version
+func GetRedisVersionsKey(c *Connector) string {
+	return keys.Versions(c.Group, c.Name)
+}
+
+// GetRedisVersionCounterKey is the last version number allocated to the configuration of the connector
+func GetRedisVersionCounterKey(c *Connector) string {
+	return keys.VersionCounter(c.Group, c.Name)
+}
+
+// redisVersionAttempts is the number of times a new version is saved again when a concurrent save changes its keys
+const redisVersionAttempts = 10
+
+// redisLastVersion returns the last version number allocated to the connector, the counter or the last version of
+// the history when it has been written before the counter
+func redisLastVersion(r intools.RedisWrapper, c *Connector) (int64, error) {
+	last, err := r.Get(GetRedisVersionCounterKey(c)).Int64()
+	if err != nil && err != redis.Nil {
+		return 0, err
+	}
+	versions, err := r.ZRevRangeWithScores(GetRedisVersionsKey(c), 0, 0).Result()
+	if err != nil {
+		return 0, err
+	}
+	if len(versions) > 0 && int64(versions[0].Score) > last {
+		last = int64(versions[0].Score)
+	}
+	return last, nil
+}
+
+// RedisSaveConnectorVersion saves the connector with a new version, whose number is allocated in the same
+// transaction. Its configuration, the counter and the history are watched, so that a concurrent save makes it
+// start again.
+func RedisSaveConnectorVersion(c *Connector, version *ConfigVersion, etag *string) error {
+	r, err := intools.Engine.GetRedisClient()
+	if err != nil {
+		return err
+	}
+	watched := []string{GetRedisConnectorConfKey(c.Group, c.Name), GetRedisVersionCounterKey(c), GetRedisVersionsKey(c)}
+	var sVersion []byte
+	check := func(r intools.RedisWrapper) error {
+		if etag != nil {
+			err := checkRedisETag(r, c, *etag)
+			if err != nil {
+				return err
+			}
+		}
+		last, err := redisLastVersion(r, c)
+		if err != nil {
+			return err
+		}
+		setVersion(c, version, int(last)+1)
+		sVersion, err = json.Marshal(version)
+		return err
+	}
+	for attempt := 0; attempt < redisVersionAttempts; attempt++ {
+		log.Debugf("Saving %s:%s to redis with a new version", c.Group, c.Name)
+		err = intools.RedisCheckAndSet(r, watched, check, func(tx intools.RedisWrapper) error {
+			tx.Set(GetRedisVersionCounterKey(c), strconv.Itoa(c.Version), 0)
+			tx.ZAdd(GetRedisVersionsKey(c), redis.Z{Score: float64(c.Version), Member: string(sVersion)})
+			tx.SAdd(GetRedisConnectorsKey(c), c.Name)
+			tx.Set(GetRedisConnectorConfKey(c.Group, c.Name), c.GetJSON(), 0)
+			tx.Incr(GetRedisGroupVersionKey(c.Group))
+			return nil
+		})
+		if err != intools.ErrKeyModified {
+			return err
+		}
+	}
+	if etag != nil {
+		return ErrModified
+	}
+	return err
+}
+
+// RedisGetLastConfigVersion returns the most recent version of the configuration of the connector, none when it
+// has no history
+func RedisGetLastConfigVersion(c *Connector) ([]string, error) {
+	r, err := intools.Engine.GetRedisClient()
+	if err != nil {
+		return nil, err
+	}
+	return r.ZRevRange(GetRedisVersionsKey(c), 0, 0).Result()
+}
+
+// RedisGetConfigVersions returns the versions of the configuration of the connector, most recent first
+func RedisGetConfigVersions(c *Connector) ([]string, error) {
+	r, err := intools.Engine.GetRedisClient()
+	if err != nil {
+		return nil, err
+	}
+	return r.ZRevRange(GetRedisVersionsKey(c), 0, -1).Result()
+}
+
+func RedisGetConfigVersion(c *Connector, version int) ([]string, error) {
+	r, err := intools.Engine.GetRedisClient()
+	if err != nil {
+		return nil, err
+	}
+	score := strconv.Itoa(version)
+	return r.ZRangeByScore(GetRedisVersionsKey(c), redis.ZRangeByScore{Min: score, Max: score}).Result()
+}
+
 func GetRedisExecutorKey(c *Connector) string {
-	return "intools:groups:" + c.Group + ":connectors:" + c.Name + ":executors"
+	return keys.Executor(c.Group, c.Name)
 }
 
 func GetRedisResultKey(c *Connector) string {
-	return "intools:groups:" + c.Group + ":connectors:" + c.Name + ":results"
+	return keys.Result(c.Group, c.Name)
+}
+
+// GetRedisExecutionsKey is the sorted set of the ids of the executions of the connector, scored by creation time
+func GetRedisExecutionsKey(c *Connector) string {
+	return keys.Executions(c.Group, c.Name)
+}
+
+func GetRedisExecutionKey(c *Connector, id string) string {
+	return keys.Execution(c.Group, c.Name, id)
+}
+
+func getExecutionScore(exec *executors.Executor) float64 {
+	return float64(getTimeScore(exec.CreatedAt))
 }
 
 func RedisSaveExecutor(c *Connector, exec *executors.Executor) error {
@@ -120,13 +313,179 @@ func RedisSaveExecutor(c *Connector, exec *executors.Executor) error {
 	if err != nil {
 		return err
 	}
-	defer r.Close()
 	log.WithField("containerName", c.GetContainerName()).WithField("containerId", exec.ContainerId).Debug("Saving execution of connector to Redis")
-	cmd := r.Set(GetRedisExecutorKey(c), exec.GetJSON(), 0)
-	if exec.Valid {
-		_ = r.Set(GetRedisResultKey(c), exec.GetResult(), 0)
+	json := exec.GetJSON()
+	err = intools.RedisTransaction(r, func(tx intools.RedisWrapper) error {
+		tx.Set(GetRedisExecutorKey(c), json, 0)
+		tx.Set(GetRedisExecutionKey(c, exec.Id), json, 0)
+		tx.ZAdd(GetRedisExecutionsKey(c), redis.Z{Score: getExecutionScore(exec), Member: exec.Id})
+		if exec.Valid {
+			tx.Set(GetRedisResultKey(c), exec.GetResult(), 0)
+		}
+		return nil
+	})
+	return err
+}
+
+func RedisGetExecution(c *Connector, id string) (string, error) {
+	r, err := intools.Engine.GetRedisClient()
+	if err != nil {
+		return "", err
 	}
-	return cmd.Err()
+	return r.Get(GetRedisExecutionKey(c, id)).Result()
+}
+
+// RedisGetExecutions returns the most recent executions of the connector, created before the execution
+// with the given id when not empty. Executions are ordered by (score, id) as ZREVRANGE does : several executions
+// created in the same millisecond share a score, the page then starts inclusively at the score of the cursor and
+// skips its executions up to the cursor.
+func RedisGetExecutions(c *Connector, limit int64, before string) ([]string, error) {
+	r, err := intools.Engine.GetRedisClient()
+	if err != nil {
+		return nil, err
+	}
+
+	if before == "" {
+		ids, err := r.ZRevRange(GetRedisExecutionsKey(c), 0, limit-1).Result()
+		if err != nil {
+			return nil, err
+		}
+		return redisGetExecutions(r, c, ids)
+	}
+
+	score, err := r.ZScore(GetRedisExecutionsKey(c), before).Result()
+	if err == redis.Nil {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	max := strconv.FormatFloat(score, 'f', -1, 64)
+	ties, err := r.ZCount(GetRedisExecutionsKey(c), max, max).Result()
+	if err != nil {
+		return nil, err
+	}
+	page, err := r.ZRevRangeByScoreWithScores(GetRedisExecutionsKey(c), redis.ZRangeByScore{
+		Min:   "-inf",
+		Max:   max,
+		Count: limit + ties,
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+	return redisGetExecutions(r, c, redisPageAfter(page, score, before, limit))
+}
+
+// redisPageAfter returns the ids of at most limit executions of page, in ZREVRANGE order, which come after the
+// execution before of the given score
+func redisPageAfter(page []redis.Z, score float64, before string, limit int64) []string {
+	ids := []string{}
+	for _, z := range page {
+		id, _ := z.Member.(string)
+		if z.Score > score || z.Score == score && id >= before {
+			continue
+		}
+		if int64(len(ids)) == limit {
+			break
+		}
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func redisGetExecutions(r intools.RedisWrapper, c *Connector, ids []string) ([]string, error) {
+	if len(ids) == 0 {
+		return []string{}, nil
+	}
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = GetRedisExecutionKey(c, id)
+	}
+	values, err := intools.RedisMGet(r, keys...)
+	if err != nil {
+		return nil, err
+	}
+	executions := []string{}
+	for _, v := range values {
+		if s, ok := v.(string); ok {
+			executions = append(executions, s)
+		}
+	}
+	return executions, nil
+}
+
+// RedisGetExpiredExecutions returns the ids of the executions beyond the size of the history, or older than its max age.
+// Limits are ignored when zero.
+func RedisGetExpiredExecutions(c *Connector, size int64, maxAge time.Duration) ([]string, error) {
+	r, err := intools.Engine.GetRedisClient()
+	if err != nil {
+		return nil, err
+	}
+
+	expired := map[string]bool{}
+	if size > 0 {
+		ids, err := r.ZRange(GetRedisExecutionsKey(c), 0, -size-1).Result()
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range ids {
+			expired[id] = true
+		}
+	}
+	if maxAge > 0 {
+		cutoff := time.Now().Add(-maxAge).UnixNano() / int64(time.Millisecond)
+		ids, err := r.ZRangeByScore(GetRedisExecutionsKey(c), redis.ZRangeByScore{
+			Min: "-inf",
+			Max: "(" + strconv.FormatInt(cutoff, 10),
+		}).Result()
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range ids {
+			expired[id] = true
+		}
+	}
+
+	ids := make([]string, 0, len(expired))
+	for id := range expired {
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// RedisRemoveExecutions removes executions from the history, and returns them
+func RedisRemoveExecutions(c *Connector, ids []string) ([]string, error) {
+	if len(ids) == 0 {
+		return []string{}, nil
+	}
+	r, err := intools.Engine.GetRedisClient()
+	if err != nil {
+		return nil, err
+	}
+
+	executions, err := redisGetExecutions(r, c, ids)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = GetRedisExecutionKey(c, id)
+	}
+	err = intools.RedisTransaction(r, func(tx intools.RedisWrapper) error {
+		intools.RedisDel(tx, keys...)
+		tx.ZRem(GetRedisExecutionsKey(c), ids...)
+		return nil
+	})
+	return executions, err
+}
+
+// RedisGetExecutionIds returns the ids of all the executions of the connector
+func RedisGetExecutionIds(c *Connector) ([]string, error) {
+	r, err := intools.Engine.GetRedisClient()
+	if err != nil {
+		return nil, err
+	}
+	return r.ZRange(GetRedisExecutionsKey(c), 0, -1).Result()
 }
 
 func RedisGetLastExecutor(c *Connector) (string, error) {
@@ -134,10 +493,477 @@ func RedisGetLastExecutor(c *Connector) (string, error) {
 	if err != nil {
 		return "", err
 	}
-	defer r.Close()
 	cmd := r.Get(GetRedisExecutorKey(c))
 	if cmd.Err() != nil {
 		return "", cmd.Err()
 	}
 	return cmd.Val(), nil
 }
+
+func RedisGetLastResult(c *Connector) (string, error) {
+	r, err := intools.Engine.GetRedisClient()
+	if err != nil {
+		return "", err
+	}
+	cmd := r.Get(GetRedisResultKey(c))
+	if cmd.Err() != nil {
+		return "", cmd.Err()
+	}
+	return cmd.Val(), nil
+}
+
+// GetRedisSeriesKey is the sorted set of the points of a series of the connector, scored by time in milliseconds
+func GetRedisSeriesKey(c *Connector, name string) string {
+	return keys.Series(c.Group, c.Name, name)
+}
+
+func getTimeScore(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
+
+func RedisAddPoint(c *Connector, name string, point Point) error {
+	r, err := intools.Engine.GetRedisClient()
+	if err != nil {
+		return err
+	}
+	score := getTimeScore(point.Time)
+	// Members of a sorted set are unique, the time makes equal values distinct
+	member := strconv.FormatInt(score, 10) + ":" + strconv.FormatFloat(point.Value, 'g', -1, 64)
+	return r.ZAdd(GetRedisSeriesKey(c, name), redis.Z{Score: float64(score), Member: member}).Err()
+}
+
+func RedisGetPoints(c *Connector, name string, from time.Time, to time.Time) ([]Point, error) {
+	r, err := intools.Engine.GetRedisClient()
+	if err != nil {
+		return nil, err
+	}
+	members, err := r.ZRangeByScore(GetRedisSeriesKey(c, name), redis.ZRangeByScore{
+		Min: strconv.FormatInt(getTimeScore(from), 10),
+		Max: strconv.FormatInt(getTimeScore(to), 10),
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+	points := make([]Point, 0, len(members))
+	for _, member := range members {
+		parts := strings.SplitN(member, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		ms, err := strconv.ParseInt(parts[0], 10, 64)
+		if err != nil {
+			continue
+		}
+		value, err := strconv.ParseFloat(parts[1], 64)
+		if err != nil {
+			continue
+		}
+		points = append(points, Point{Time: time.Unix(0, ms*int64(time.Millisecond)).UTC(), Value: value})
+	}
+	return points, nil
+}
+
+func RedisSaveLastResult(c *Connector, result string) error {
+	r, err := intools.Engine.GetRedisClient()
+	if err != nil {
+		return err
+	}
+	return r.Set(GetRedisResultKey(c), result, 0).Err()
+}
+
+func RedisRemoveLastResult(c *Connector) error {
+	r, err := intools.Engine.GetRedisClient()
+	if err != nil {
+		return err
+	}
+	return r.Del(GetRedisResultKey(c)).Err()
+}
+
+func GetRedisRetentionKey(group string) string {
+	return keys.Retention(group)
+}
+
+func RedisGetGroupRetention(group string) (string, error) {
+	r, err := intools.Engine.GetRedisClient()
+	if err != nil {
+		return "", err
+	}
+	return r.Get(GetRedisRetentionKey(group)).Result()
+}
+
+func RedisSaveGroupRetention(group string, retention *Retention) error {
+	r, err := intools.Engine.GetRedisClient()
+	if err != nil {
+		return err
+	}
+	b, err := json.Marshal(retention)
+	if err != nil {
+		return err
+	}
+	return intools.RedisTransaction(r, func(tx intools.RedisWrapper) error {
+		if retention == nil {
+			tx.Del(GetRedisRetentionKey(group))
+		} else {
+			tx.Set(GetRedisRetentionKey(group), string(b), 0)
+		}
+		tx.Incr(GetRedisGroupVersionKey(group))
+		return nil
+	})
+}
+
+func GetRedisDefaultsKey(group string) string {
+	return keys.Defaults(group)
+}
+
+func RedisGetGroupDefaults(group string) (string, error) {
+	r, err := intools.Engine.GetRedisClient()
+	if err != nil {
+		return "", err
+	}
+	return r.Get(GetRedisDefaultsKey(group)).Result()
+}
+
+func RedisSaveGroupDefaults(group string, defaults *Defaults) error {
+	r, err := intools.Engine.GetRedisClient()
+	if err != nil {
+		return err
+	}
+	b, err := json.Marshal(defaults)
+	if err != nil {
+		return err
+	}
+	return intools.RedisTransaction(r, func(tx intools.RedisWrapper) error {
+		if defaults == nil {
+			tx.Del(GetRedisDefaultsKey(group))
+		} else {
+			tx.Set(GetRedisDefaultsKey(group), string(b), 0)
+		}
+		tx.Incr(GetRedisGroupVersionKey(group))
+		return nil
+	})
+}
+
+func GetRedisGroupVersionKey(group string) string {
+	return keys.GroupVersion(group)
+}
+
+// RedisGetGroupVersion returns the version of the group, zero before its first change
+func RedisGetGroupVersion(group string) (int64, error) {
+	r, err := intools.Engine.GetRedisClient()
+	if err != nil {
+		return 0, err
+	}
+	version, err := r.Get(GetRedisGroupVersionKey(group)).Int64()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	return version, err
+}
+
+func RedisBumpGroupVersion(group string) error {
+	r, err := intools.Engine.GetRedisClient()
+	if err != nil {
+		return err
+	}
+	return r.Incr(GetRedisGroupVersionKey(group)).Err()
+}
+
+// parseRedisConnectorKey returns the group and the connector of a key under the prefix of a connector
+func parseRedisConnectorKey(key string) (string, string, bool) {
+	rest := strings.TrimPrefix(key, keys.Groups()+":")
+	i := strings.Index(rest, ":connectors:")
+	if i < 0 {
+		return "", "", false
+	}
+	group := rest[:i]
+	connector := rest[i+len(":connectors:"):]
+	if j := strings.Index(connector, ":"); j >= 0 {
+		connector = connector[:j]
+	}
+	return group, connector, true
+}
+
+// RedisRemoveOrphans removes the keys under the prefix of connectors without configuration, and returns their number
+func RedisRemoveOrphans() (int, error) {
+	r, err := intools.Engine.GetRedisClient()
+	if err != nil {
+		return 0, err
+	}
+
+	exists := map[string]bool{}
+	removed := 0
+	pattern := intools.EscapeRedisPattern(keys.Groups()) + ":*:connectors:*"
+	err = intools.RedisScan(r, pattern, func(scanned []string) error {
+		orphans := []string{}
+		for _, key := range scanned {
+			group, connector, ok := parseRedisConnectorKey(key)
+			if !ok {
+				continue
+			}
+			id := group + ":" + connector
+			if _, checked := exists[id]; !checked {
+				found, err := r.Exists(GetRedisConnectorConfKey(group, connector)).Result()
+				if err != nil {
+					return err
+				}
+				exists[id] = found
+			}
+			if !exists[id] {
+				orphans = append(orphans, key)
+			}
+		}
+		removed += len(orphans)
+		return intools.RedisDel(r, orphans...)
+	})
+	return removed, err
+}
+
+// RedisConnectorRepository is the ConnectorRepository backed by the Redis server of the engine
+type RedisConnectorRepository struct{}
+
+func NewRedisConnectorRepository() *RedisConnectorRepository {
+	return &RedisConnectorRepository{}
+}
+
+func parseRedisExecutor(sExecutor string) (*executors.Executor, error) {
+	executor := &executors.Executor{}
+	err := json.Unmarshal([]byte(sExecutor), executor)
+	if err != nil {
+		return nil, err
+	}
+	return executor, nil
+}
+
+func parseRedisExecutors(sExecutors []string) []*executors.Executor {
+	executions := make([]*executors.Executor, 0, len(sExecutors))
+	for _, s := range sExecutors {
+		executor, err := parseRedisExecutor(s)
+		if err != nil {
+			log.WithError(err).Warn("Cannot parse execution")
+			continue
+		}
+		executions = append(executions, executor)
+	}
+	return executions
+}
+
+func (r *RedisConnectorRepository) GetConnectors(group string) ([]string, error) {
+	return RedisGetConnectors(group)
+}
+
+func (r *RedisConnectorRepository) GetConnector(group string, connector string) (*Connector, error) {
+	return RedisGetConnector(group, connector)
+}
+
+func (r *RedisConnectorRepository) ConnectorExists(group string, connector string) (bool, error) {
+	return RedisConnectorExists(group, connector)
+}
+
+func (r *RedisConnectorRepository) SaveConnector(c *Connector) error {
+	return RedisSaveConnector(c)
+}
+
+func (r *RedisConnectorRepository) RemoveConnector(c *Connector) error {
+	return RedisRemoveConnector(c)
+}
+
+func (r *RedisConnectorRepository) SaveConnectorIf(c *Connector, etag string) error {
+	return RedisSaveConnectorIf(c, etag)
+}
+
+func (r *RedisConnectorRepository) RemoveConfigIf(c *Connector, etag string) error {
+	return RedisRemoveConfigIf(c, etag)
+}
+
+func (r *RedisConnectorRepository) SaveExecutor(c *Connector, exec *executors.Executor) error {
+	return RedisSaveExecutor(c, exec)
+}
+
+func (r *RedisConnectorRepository) GetLastExecutor(c *Connector) (*executors.Executor, error) {
+	sExecutor, err := RedisGetLastExecutor(c)
+	if err == redis.Nil {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return parseRedisExecutor(sExecutor)
+}
+
+func (r *RedisConnectorRepository) GetLastResult(c *Connector) (*map[string]interface{}, error) {
+	sResult, err := RedisGetLastResult(c)
+	if err == redis.Nil {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	result := &map[string]interface{}{}
+	err = json.Unmarshal([]byte(sResult), result)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (r *RedisConnectorRepository) GetExecution(c *Connector, id string) (*executors.Executor, error) {
+	sExecution, err := RedisGetExecution(c, id)
+	if err == redis.Nil {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return parseRedisExecutor(sExecution)
+}
+
+func (r *RedisConnectorRepository) GetExecutions(c *Connector, limit int64, before string) ([]*executors.Executor, error) {
+	sExecutions, err := RedisGetExecutions(c, limit, before)
+	if err == redis.Nil {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return parseRedisExecutors(sExecutions), nil
+}
+
+func (r *RedisConnectorRepository) GetExpiredExecutions(c *Connector, size int64, maxAge time.Duration) ([]string, error) {
+	return RedisGetExpiredExecutions(c, size, maxAge)
+}
+
+func (r *RedisConnectorRepository) GetExecutionIds(c *Connector) ([]string, error) {
+	return RedisGetExecutionIds(c)
+}
+
+func (r *RedisConnectorRepository) RemoveExecutions(c *Connector, ids []string) ([]*executors.Executor, error) {
+	sExecutions, err := RedisRemoveExecutions(c, ids)
+	if err != nil {
+		return nil, err
+	}
+	return parseRedisExecutors(sExecutions), nil
+}
+
+func (r *RedisConnectorRepository) AddPoint(c *Connector, name string, point Point) error {
+	return RedisAddPoint(c, name, point)
+}
+
+func (r *RedisConnectorRepository) GetPoints(c *Connector, name string, from time.Time, to time.Time) ([]Point, error) {
+	return RedisGetPoints(c, name, from, to)
+}
+
+func (r *RedisConnectorRepository) SaveLastResult(c *Connector, result *map[string]interface{}) error {
+	b, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	return RedisSaveLastResult(c, string(b))
+}
+
+func (r *RedisConnectorRepository) RemoveLastResult(c *Connector) error {
+	return RedisRemoveLastResult(c)
+}
+
+func (r *RedisConnectorRepository) GetGroupRetention(group string) (*Retention, error) {
+	sRetention, err := RedisGetGroupRetention(group)
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	retention := &Retention{}
+	err = json.Unmarshal([]byte(sRetention), retention)
+	if err != nil {
+		return nil, err
+	}
+	return retention, nil
+}
+
+func (r *RedisConnectorRepository) SaveGroupRetention(group string, retention *Retention) error {
+	return RedisSaveGroupRetention(group, retention)
+}
+
+func (r *RedisConnectorRepository) GetGroupDefaults(group string) (*Defaults, error) {
+	sDefaults, err := RedisGetGroupDefaults(group)
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defaults := &Defaults{}
+	err = json.Unmarshal([]byte(sDefaults), defaults)
+	if err != nil {
+		return nil, err
+	}
+	return defaults, nil
+}
+
+func (r *RedisConnectorRepository) SaveGroupDefaults(group string, defaults *Defaults) error {
+	return RedisSaveGroupDefaults(group, defaults)
+}
+
+func (r *RedisConnectorRepository) GetGroupVersion(group string) (int64, error) {
+	return RedisGetGroupVersion(group)
+}
+
+func (r *RedisConnectorRepository) BumpGroupVersion(group string) error {
+	return RedisBumpGroupVersion(group)
+}
+
+func (r *RedisConnectorRepository) RemoveOrphans() (int, error) {
+	return RedisRemoveOrphans()
+}
+
+func parseRedisConfigVersion(sVersion string) (*ConfigVersion, error) {
+	version := &ConfigVersion{}
+	err := json.Unmarshal([]byte(sVersion), version)
+	if err != nil {
+		return nil, err
+	}
+	return version, nil
+}
+
+func (r *RedisConnectorRepository) SaveConnectorVersion(c *Connector, version *ConfigVersion, etag *string) error {
+	return RedisSaveConnectorVersion(c, version, etag)
+}
+
+func (r *RedisConnectorRepository) GetLastConfigVersion(c *Connector) (*ConfigVersion, error) {
+	sVersions, err := RedisGetLastConfigVersion(c)
+	if err != nil {
+		return nil, err
+	}
+	if len(sVersions) == 0 {
+		return nil, ErrNotFound
+	}
+	return parseRedisConfigVersion(sVersions[0])
+}
+
+func (r *RedisConnectorRepository) GetConfigVersions(c *Connector) ([]*ConfigVersion, error) {
+	sVersions, err := RedisGetConfigVersions(c)
+	if err != nil {
+		return nil, err
+	}
+	versions := []*ConfigVersion{}
+	for _, sVersion := range sVersions {
+		version, err := parseRedisConfigVersion(sVersion)
+		if err != nil {
+			log.WithError(err).Warnf("Invalid version of connector %s:%s", c.Group, c.Name)
+			continue
+		}
+		versions = append(versions, version)
+	}
+	return versions, nil
+}
+
+func (r *RedisConnectorRepository) GetConfigVersion(c *Connector, version int) (*ConfigVersion, error) {
+	sVersions, err := RedisGetConfigVersion(c, version)
+	if err != nil {
+		return nil, err
+	}
+	if len(sVersions) == 0 {
+		return nil, ErrNotFound
+	}
+	return parseRedisConfigVersion(sVersions[0])
+}
@@ -0,0 +1,39 @@
+package connectors
+
+import (
+	"reflect"
+	"testing"
+
+	"gopkg.in/redis.v3"
+)
+
+func TestRedisPageAfter(t *testing.T) {
+	// As ZREVRANGEBYSCORE answers them from the score of the cursor, "b", "c" and "d" share a millisecond
+	page := []redis.Z{
+		{Score: 2000, Member: "d"},
+		{Score: 2000, Member: "c"},
+		{Score: 2000, Member: "b"},
+		{Score: 1000, Member: "z"},
+		{Score: 1000, Member: "a"},
+	}
+	tests := []struct {
+		name   string
+		before string
+		limit  int64
+		ids    []string
+	}{
+		{"first of the millisecond", "d", 10, []string{"c", "b", "z", "a"}},
+		{"middle of the millisecond", "c", 10, []string{"b", "z", "a"}},
+		{"last of the millisecond", "b", 10, []string{"z", "a"}},
+		{"limit", "d", 2, []string{"c", "b"}},
+	}
+	for _, test := range tests {
+		ids := redisPageAfter(page, 2000, test.before, test.limit)
+		if !reflect.DeepEqual(ids, test.ids) {
+			t.Errorf("%s: expected %v, got %v", test.name, test.ids, ids)
+		}
+	}
+	if ids := redisPageAfter(page, 1000, "z", 10); !reflect.DeepEqual(ids, []string{"a"}) {
+		t.Errorf("Expected the executions after z, got %v", ids)
+	}
+}
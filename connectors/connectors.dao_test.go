@@ -0,0 +1,237 @@
+package connectors
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/soprasteria/intools-engine/executors"
+)
+
+// repositories returns the repositories which run the same contract, each of them empty
+func repositories(t *testing.T) map[string]func() ConnectorRepository {
+	return map[string]func() ConnectorRepository{
+		"memory": func() ConnectorRepository {
+			return NewMemoryConnectorRepository()
+		},
+		"file": func() ConnectorRepository {
+			r, err := NewFileConnectorRepository(t.TempDir())
+			if err != nil {
+				t.Fatalf("NewFileConnectorRepository failed: %s", err)
+			}
+			return r
+		},
+	}
+}
+
+func eachRepository(t *testing.T, test func(t *testing.T, r ConnectorRepository)) {
+	for name, newRepository := range repositories(t) {
+		t.Run(name, func(t *testing.T) {
+			test(t, newRepository())
+		})
+	}
+}
+
+func newExecution(id string, createdAt time.Time) *executors.Executor {
+	result := map[string]interface{}{"id": id}
+	return &executors.Executor{Id: id, CreatedAt: createdAt, Stdout: `{"id":"` + id + `"}`, JsonStdout: &result, Valid: true}
+}
+
+func TestRepositorySavesConnectors(t *testing.T) {
+	eachRepository(t, func(t *testing.T, r ConnectorRepository) {
+		for _, name := range []string{"a", "b"} {
+			if err := r.SaveConnector(&Connector{Group: "g", Name: name, Timeout: 10}); err != nil {
+				t.Fatalf("SaveConnector failed: %s", err)
+			}
+		}
+
+		c, err := r.GetConnector("g", "a")
+		if err != nil {
+			t.Fatalf("GetConnector failed: %s", err)
+		}
+		if c.Group != "g" || c.Name != "a" || c.Timeout != 10 {
+			t.Errorf("Unexpected connector %+v", c)
+		}
+		names, err := r.GetConnectors("g")
+		if err != nil {
+			t.Fatalf("GetConnectors failed: %s", err)
+		}
+		sort.Strings(names)
+		if !reflect.DeepEqual(names, []string{"a", "b"}) {
+			t.Errorf("Unexpected connectors %v", names)
+		}
+		if exists, _ := r.ConnectorExists("g", "c"); exists {
+			t.Error("Expected connector c not to exist")
+		}
+		if _, err = r.GetConnector("g", "c"); err == nil {
+			t.Error("Expected an error for a missing connector")
+		}
+	})
+}
+
+func TestRepositoryChecksETags(t *testing.T) {
+	eachRepository(t, func(t *testing.T, r ConnectorRepository) {
+		c := &Connector{Group: "g", Name: "a", Timeout: 10}
+		if err := r.SaveConnectorIf(c, `"wrong"`); err != ErrModified {
+			t.Errorf("Expected ErrModified for a missing connector, got %v", err)
+		}
+		if err := r.SaveConnectorIf(c, ""); err != nil {
+			t.Fatalf("SaveConnectorIf failed: %s", err)
+		}
+		etag := c.ETag()
+
+		updated := &Connector{Group: "g", Name: "a", Timeout: 20}
+		if err := r.SaveConnectorIf(updated, ""); err != ErrModified {
+			t.Errorf("Expected ErrModified for an existing connector, got %v", err)
+		}
+		if err := r.SaveConnectorIf(updated, `"wrong"`); err != ErrModified {
+			t.Errorf("Expected ErrModified for a wrong ETag, got %v", err)
+		}
+		if stored, _ := r.GetConnector("g", "a"); stored.Timeout != 10 {
+			t.Errorf("Expected a refused save to keep the connector, got timeout %d", stored.Timeout)
+		}
+		if err := r.SaveConnectorIf(updated, etag); err != nil {
+			t.Fatalf("SaveConnectorIf failed: %s", err)
+		}
+
+		if err := r.RemoveConfigIf(updated, etag); err != ErrModified {
+			t.Errorf("Expected ErrModified for a stale ETag, got %v", err)
+		}
+		if exists, _ := r.ConnectorExists("g", "a"); !exists {
+			t.Error("Expected a refused removal to keep the connector")
+		}
+		if err := r.RemoveConfigIf(updated, updated.ETag()); err != nil {
+			t.Fatalf("RemoveConfigIf failed: %s", err)
+		}
+		if exists, _ := r.ConnectorExists("g", "a"); exists {
+			t.Error("Expected the connector to be removed")
+		}
+		if names, _ := r.GetConnectors("g"); len(names) != 0 {
+			t.Errorf("Expected no connector left, got %v", names)
+		}
+	})
+}
+
+func TestRepositoryNumbersVersions(t *testing.T) {
+	eachRepository(t, func(t *testing.T, r ConnectorRepository) {
+		c := &Connector{Group: "g", Name: "a", Timeout: 10}
+		if _, err := r.GetLastConfigVersion(c); err != ErrNotFound {
+			t.Errorf("Expected ErrNotFound without versions, got %v", err)
+		}
+		if err := r.SaveConnectorVersion(c, &ConfigVersion{}, nil); err != nil {
+			t.Fatalf("SaveConnectorVersion failed: %s", err)
+		}
+		etag := c.ETag()
+
+		wrong := `"wrong"`
+		refused := &Connector{Group: "g", Name: "a", Timeout: 20}
+		if err := r.SaveConnectorVersion(refused, &ConfigVersion{}, &wrong); err != ErrModified {
+			t.Errorf("Expected ErrModified for a wrong ETag, got %v", err)
+		}
+		updated := &Connector{Group: "g", Name: "a", Timeout: 30}
+		version := &ConfigVersion{Author: "me"}
+		if err := r.SaveConnectorVersion(updated, version, &etag); err != nil {
+			t.Fatalf("SaveConnectorVersion failed: %s", err)
+		}
+		if updated.Version != 2 || version.Version != 2 || version.Connector.Timeout != 30 {
+			t.Errorf("Expected a refused save to take no version number, got %d", updated.Version)
+		}
+
+		last, err := r.GetLastConfigVersion(c)
+		if err != nil {
+			t.Fatalf("GetLastConfigVersion failed: %s", err)
+		}
+		if last.Version != 2 || last.Author != "me" || last.Connector.Version != 2 {
+			t.Errorf("Unexpected last version %+v", last)
+		}
+		if stored, _ := r.GetConnector("g", "a"); stored.Timeout != 30 || stored.Version != 2 {
+			t.Errorf("Unexpected connector %+v", stored)
+		}
+		if versions, _ := r.GetConfigVersions(c); len(versions) != 2 || versions[1].Version != 1 {
+			t.Errorf("Unexpected versions %v", versions)
+		}
+	})
+}
+
+func TestRepositoryRemovesExecutions(t *testing.T) {
+	eachRepository(t, func(t *testing.T, r ConnectorRepository) {
+		c := &Connector{Group: "g", Name: "a"}
+		r.SaveConnector(c)
+		now := time.Now().UTC()
+		for i, id := range []string{"1", "2", "3"} {
+			if err := r.SaveExecutor(c, newExecution(id, now.Add(time.Duration(i)*time.Second))); err != nil {
+				t.Fatalf("SaveExecutor failed: %s", err)
+			}
+		}
+
+		removed, err := r.RemoveExecutions(c, []string{"1", "3", "unknown"})
+		if err != nil {
+			t.Fatalf("RemoveExecutions failed: %s", err)
+		}
+		ids := []string{}
+		for _, exec := range removed {
+			ids = append(ids, exec.Id)
+		}
+		sort.Strings(ids)
+		if !reflect.DeepEqual(ids, []string{"1", "3"}) {
+			t.Errorf("Unexpected removed executions %v", ids)
+		}
+		if ids, _ = r.GetExecutionIds(c); !reflect.DeepEqual(ids, []string{"2"}) {
+			t.Errorf("Unexpected executions left %v", ids)
+		}
+		if _, err = r.GetExecution(c, "1"); err != ErrNotFound {
+			t.Errorf("Expected ErrNotFound for a removed execution, got %v", err)
+		}
+	})
+}
+
+func TestFileRepositoryReloads(t *testing.T) {
+	dir := t.TempDir()
+	r, err := NewFileConnectorRepository(dir)
+	if err != nil {
+		t.Fatalf("NewFileConnectorRepository failed: %s", err)
+	}
+	c := &Connector{Group: "g", Name: "a/b", Timeout: 10}
+	r.SaveConnector(c)
+	removed := &Connector{Group: "g", Name: "removed"}
+	r.SaveConnector(removed)
+	r.RemoveConfigIf(removed, removed.ETag())
+	now := time.Now().UTC()
+	r.SaveExecutor(c, newExecution("1", now))
+	r.SaveExecutor(c, newExecution("2", now.Add(time.Second)))
+	r.RemoveExecutions(c, []string{"1"})
+	r.AddPoint(c, "size", Point{Time: now, Value: 1})
+	r.SaveConnectorVersion(c, &ConfigVersion{}, nil)
+	r.SaveGroupRetention("g", &Retention{MaxExecutions: 5})
+
+	// An engine restarted on the same directory
+	r, err = NewFileConnectorRepository(dir)
+	if err != nil {
+		t.Fatalf("NewFileConnectorRepository failed: %s", err)
+	}
+	if names, _ := r.GetConnectors("g"); !reflect.DeepEqual(names, []string{"a/b"}) {
+		t.Errorf("Unexpected connectors %v", names)
+	}
+	if stored, err := r.GetConnector("g", "a/b"); err != nil || stored.Timeout != 10 {
+		t.Errorf("Unexpected connector %+v, %v", stored, err)
+	}
+	if ids, _ := r.GetExecutionIds(c); !reflect.DeepEqual(ids, []string{"2"}) {
+		t.Errorf("Unexpected executions %v", ids)
+	}
+	if last, err := r.GetLastExecutor(c); err != nil || last.Id != "2" {
+		t.Errorf("Unexpected last execution %+v, %v", last, err)
+	}
+	if result, err := r.GetLastResult(c); err != nil || (*result)["id"] != "2" {
+		t.Errorf("Unexpected last result %v, %v", result, err)
+	}
+	if points, _ := r.GetPoints(c, "size", now.Add(-time.Minute), now.Add(time.Minute)); len(points) != 1 || points[0].Value != 1 {
+		t.Errorf("Unexpected points %v", points)
+	}
+	if versions, _ := r.GetConfigVersions(c); len(versions) != 1 || versions[0].Version != 1 {
+		t.Errorf("Unexpected versions %v", versions)
+	}
+	if retention, _ := r.GetGroupRetention("g"); retention == nil || retention.MaxExecutions != 5 {
+		t.Errorf("Unexpected retention %v", retention)
+	}
+}
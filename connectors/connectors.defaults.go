@@ -0,0 +1,220 @@
+package connectors
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/soprasteria/dockerapi"
+)
+
+// Resources limits the resources of the containers of connectors, like the HostConfig of Docker
+type Resources struct {
+	// Memory in bytes
+	Memory int64 `json:"memory,omitempty"`
+	// MemorySwap in bytes, memory included
+	MemorySwap int64 `json:"memorySwap,omitempty"`
+	// CpuShares is the relative weight of the containers
+	CpuShares int64 `json:"cpuShares,omitempty"`
+}
+
+// Defaults are the settings of a group inherited by its connectors which do not set them
+type Defaults struct {
+	Timeout uint `json:"timeout,omitempty"`
+	Refresh uint `json:"refresh,omitempty"`
+	// Env are KEY=VALUE variables added to the environment of the containers which do not set them
+	Env       []string   `json:"env,omitempty"`
+	Resources *Resources `json:"resources,omitempty"`
+	// Registry is put before the images which do not name their registry
+	Registry string `json:"registry,omitempty"`
+}
+
+func checkTimeout(timeout uint) error {
+	if timeout != 0 && (timeout < MinTimeout || timeout > MaxTimeout) {
+		return fmt.Errorf("must be between %d and %d seconds", MinTimeout, MaxTimeout)
+	}
+	return nil
+}
+
+func checkRefresh(refresh uint) error {
+	if refresh != 0 && (refresh < MinRefresh || refresh > MaxRefresh) {
+		return fmt.Errorf("must be between %d and %d minutes", MinRefresh, MaxRefresh)
+	}
+	return nil
+}
+
+// CheckDefaults validates the defaults of a group, and returns their invalid fields, nil when they are valid
+func CheckDefaults(d *Defaults) ValidationErrors {
+	if d == nil {
+		return nil
+	}
+	errors := ValidationErrors{}
+	add := func(field string, err error) {
+		if err != nil {
+			errors = append(errors, FieldError{Field: "defaults." + field, Message: err.Error()})
+		}
+	}
+
+	add("timeout", checkTimeout(d.Timeout))
+	add("refresh", checkRefresh(d.Refresh))
+	for i, variable := range d.Env {
+		if strings.Index(variable, "=") < 1 {
+			add(fmt.Sprintf("env[%d]", i), fmt.Errorf("must be KEY=VALUE"))
+		}
+	}
+	if d.Resources != nil && (d.Resources.Memory < 0 || d.Resources.MemorySwap < 0 || d.Resources.CpuShares < 0) {
+		add("resources", fmt.Errorf("cannot be negative"))
+	}
+	if strings.Contains(d.Registry, "://") {
+		add("registry", fmt.Errorf("must be a host, without scheme"))
+	}
+
+	if len(errors) == 0 {
+		return nil
+	}
+	return errors
+}
+
+// GetGroupDefaults returns the defaults of the connectors of a group, nil when not set
+func GetGroupDefaults(group string) (*Defaults, error) {
+	return Repository.GetGroupDefaults(group)
+}
+
+// SetGroupDefaults sets the defaults of the connectors of a group, nil removes them.
+// The scheduled connectors which inherit their refresh are rescheduled when it changes.
+func SetGroupDefaults(group string, d *Defaults) error {
+	previous, err := Repository.GetGroupDefaults(group)
+	if err != nil {
+		return err
+	}
+	err = Repository.SaveGroupDefaults(group, d)
+	if err != nil {
+		return err
+	}
+
+	var previousRefresh, refresh uint
+	if previous != nil {
+		previousRefresh = previous.Refresh
+	}
+	if d != nil {
+		refresh = d.Refresh
+	}
+	if previousRefresh == refresh {
+		return nil
+	}
+	inheriting, _, err := LoadConnectors(group)
+	if err != nil {
+		return err
+	}
+	for i := range inheriting {
+		if inheriting[i].Refresh == 0 && Scheduler.IsScheduled(&inheriting[i]) {
+			Scheduler.SetJob(&inheriting[i])
+		}
+	}
+	return nil
+}
+
+// Effective returns the connector as it runs : what it does not set comes from the defaults of its group,
+// then from the defaults of the engine
+func Effective(c *Connector) *Connector {
+	effective := *c
+	if c.ContainerConfig != nil {
+		config := *c.ContainerConfig
+		effective.ContainerConfig = &config
+	}
+	defaults, err := Repository.GetGroupDefaults(c.Group)
+	if err != nil {
+		log.WithError(err).Warnf("Cannot load defaults of group %s, using the global ones", c.Group)
+	}
+	if defaults != nil {
+		effective.inherit(defaults)
+	}
+	effective.SetDefaults()
+	return &effective
+}
+
+func (c *Connector) inherit(d *Defaults) {
+	if c.Timeout == 0 {
+		c.Timeout = d.Timeout
+	}
+	if c.Refresh == 0 {
+		c.Refresh = d.Refresh
+	}
+	if c.ContainerConfig == nil {
+		return
+	}
+	c.ContainerConfig.Env = inheritEnv(d.Env, c.ContainerConfig.Env)
+	c.ContainerConfig.Image = withRegistry(d.Registry, c.ContainerConfig.Image)
+	if d.Resources != nil {
+		config, err := withResources(c.ContainerConfig, d.Resources)
+		if err != nil {
+			log.WithError(err).Warnf("Cannot apply the resources of group %s to %s", c.Group, c.Id())
+		} else {
+			c.ContainerConfig = config
+		}
+	}
+}
+
+// inheritEnv returns the variables of env, preceded by the inherited ones which env does not set
+func inheritEnv(inherited []string, env []string) []string {
+	set := map[string]bool{}
+	for _, variable := range env {
+		set[strings.SplitN(variable, "=", 2)[0]] = true
+	}
+	merged := []string{}
+	for _, variable := range inherited {
+		if !set[strings.SplitN(variable, "=", 2)[0]] {
+			merged = append(merged, variable)
+		}
+	}
+	return append(merged, env...)
+}
+
+// withRegistry puts the registry before the image when it does not name its registry, like debian:jessie or cdk/app
+func withRegistry(registry string, image string) string {
+	if registry == "" || image == "" {
+		return image
+	}
+	parts := strings.SplitN(image, "/", 2)
+	if len(parts) == 2 && (strings.ContainsAny(parts[0], ".:") || parts[0] == "localhost") {
+		return image
+	}
+	return strings.TrimSuffix(registry, "/") + "/" + image
+}
+
+// withResources returns the options with the resources they do not limit. They are set through the JSON of the
+// options, where they are the Memory, MemorySwap and CpuShares of the HostConfig.
+func withResources(options *dockerapi.ContainerOptions, resources *Resources) (*dockerapi.ContainerOptions, error) {
+	b, err := json.Marshal(options)
+	if err != nil {
+		return nil, err
+	}
+	fields := map[string]interface{}{}
+	err = json.Unmarshal(b, &fields)
+	if err != nil {
+		return nil, err
+	}
+	hostConfig, ok := fields["HostConfig"].(map[string]interface{})
+	if !ok {
+		hostConfig = map[string]interface{}{}
+	}
+	set := func(field string, value int64) {
+		if current, ok := hostConfig[field].(float64); value == 0 || (ok && current != 0) {
+			return
+		}
+		hostConfig[field] = value
+	}
+	set("Memory", resources.Memory)
+	set("MemorySwap", resources.MemorySwap)
+	set("CpuShares", resources.CpuShares)
+	fields["HostConfig"] = hostConfig
+
+	b, err = json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+	limited := &dockerapi.ContainerOptions{}
+	err = json.Unmarshal(b, limited)
+	return limited, err
+}
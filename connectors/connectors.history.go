@@ -0,0 +1,53 @@
+package connectors
+
+import (
+	log "github.com/Sirupsen/logrus"
+	"github.com/soprasteria/intools-engine/executors"
+)
+
+// GetConnectorExecution returns an execution of the history of the connector
+func GetConnectorExecution(c *Connector, id string) (*executors.Executor, error) {
+	return Repository.GetExecution(c, id)
+}
+
+// GetConnectorExecutions returns a page of the history of the connector, most recent first.
+// When before is set, only the executions created before this execution are returned.
+func GetConnectorExecutions(c *Connector, limit int64, before string) ([]*executors.Executor, error) {
+	return Repository.GetExecutions(c, limit, before)
+}
+
+// TrimExecutions removes the executions beyond the retention of the connector, with their artifacts
+func TrimExecutions(c *Connector) {
+	_, err := trimExecutions(c, GetRetention(c))
+	if err != nil {
+		log.WithError(err).Errorf("Cannot trim executions of %s:%s", c.Group, c.Name)
+	}
+}
+
+// RemoveExecutions removes the whole history of the connector, with the artifacts
+func RemoveExecutions(c *Connector) error {
+	ids, err := Repository.GetExecutionIds(c)
+	if err == nil {
+		_, err = removeExecutions(c, ids)
+	}
+	if err != nil {
+		log.WithError(err).Errorf("Cannot remove executions of %s:%s", c.Group, c.Name)
+	}
+	return err
+}
+
+// removeExecutions removes executions from the history with their artifacts, and returns them
+func removeExecutions(c *Connector, ids []string) ([]*executors.Executor, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	executions, err := Repository.RemoveExecutions(c, ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, executor := range executions {
+		RemoveArtifacts(c, executor)
+	}
+	log.Debugf("%d executions of %s:%s removed from history", len(executions), c.Group, c.Name)
+	return executions, nil
+}
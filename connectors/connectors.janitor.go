@@ -0,0 +1,84 @@
+package connectors
+
+import (
+	"strconv"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/soprasteria/intools-engine/backends"
+	"github.com/soprasteria/intools-engine/intools"
+)
+
+// Extra time given to an execution after its timeout before its instance is considered as an orphan
+const janitorGrace = 2 * time.Minute
+
+// Sweep removes the instances of this engine left behind, the ones older than the timeout of their
+// connector and the ones belonging to deleted connectors. It returns the number of removed instances.
+func Sweep() int {
+	sweeper, ok := intools.Engine.GetBackend().(backends.Sweeper)
+	if !ok {
+		log.Debug("Backend does not support sweeping of orphan instances")
+		return 0
+	}
+
+	instances, err := sweeper.ListInstances(intools.Engine.GetEngineId())
+	if err != nil {
+		log.WithError(err).Error("Cannot list instances of the engine")
+		return 0
+	}
+
+	removed := 0
+	for _, instance := range instances {
+		if !isOrphan(instance) {
+			continue
+		}
+		log.WithFields(log.Fields{
+			"instance":  instance.Name,
+			"host":      instance.Host,
+			"execution": instance.Labels[backends.LabelExecution],
+		}).Info("Removing orphan instance")
+		err = sweeper.Remove(&instance.Instance)
+		if err != nil {
+			log.WithError(err).Warn("Cannot remove orphan instance " + instance.Name)
+			continue
+		}
+		removed++
+	}
+	log.Infof("Janitor removed %d orphan instances out of %d", removed, len(instances))
+	return removed
+}
+
+func isOrphan(instance *backends.LabelledInstance) bool {
+	group := instance.Labels[backends.LabelGroup]
+	name := instance.Labels[backends.LabelConnector]
+
+	exists, err := Repository.ConnectorExists(group, name)
+	if err != nil {
+		log.WithError(err).Warnf("Cannot check connector %s:%s, keeping its instance", group, name)
+		return false
+	}
+	if !exists {
+		return true
+	}
+
+	timeout, err := strconv.Atoi(instance.Labels[backends.LabelTimeout])
+	if err != nil {
+		conn, err := GetConnector(group, name)
+		if err != nil {
+			return false
+		}
+		timeout = int(Effective(conn).Timeout)
+	}
+	return time.Since(instance.Created) > time.Duration(timeout)*time.Second+janitorGrace
+}
+
+// StartJanitor periodically sweeps the orphan instances
+func StartJanitor(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	go func() {
+		for _ = range ticker.C {
+			Sweep()
+		}
+	}()
+	log.Infof("Janitor will sweep orphan instances every %s", interval)
+}
@@ -0,0 +1,196 @@
+package connectors
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/soprasteria/intools-engine/common/listing"
+	"github.com/soprasteria/intools-engine/executors"
+)
+
+// Fields the connectors can be sorted by
+const (
+	SortName    = "name"
+	SortLastRun = "lastRun"
+	SortStatus  = "status"
+)
+
+// Filter selects connectors, its empty fields select every connector
+type Filter struct {
+	// Prefix of the names
+	Prefix string
+	// Image of the containers, with or without its tag
+	Image string
+	// Status of the last execution
+	Status executors.Status
+	// Labels of the containers, as key=value or as key for the connectors having the label
+	Labels []string
+}
+
+// CheckFilter returns an error when the status of the filter is not a status of executions
+func CheckFilter(filter Filter) error {
+	switch filter.Status {
+	case "", executors.StatusQueued, executors.StatusRunning, executors.StatusSucceeded, executors.StatusFailed,
+		executors.StatusTimedOut, executors.StatusInvalidOutput, executors.StatusEngineError:
+		return nil
+	}
+	return fmt.Errorf("unknown status %s", filter.Status)
+}
+
+func (f Filter) matches(c *Connector) bool {
+	if !strings.HasPrefix(c.Name, f.Prefix) {
+		return false
+	}
+	if f.Image == "" && len(f.Labels) == 0 {
+		return true
+	}
+	if c.ContainerConfig == nil {
+		return false
+	}
+	image := c.ContainerConfig.Image
+	if f.Image != "" && image != f.Image && !strings.HasPrefix(image, f.Image+":") && !strings.HasPrefix(image, f.Image+"@") {
+		return false
+	}
+	for _, label := range f.Labels {
+		parts := strings.SplitN(label, "=", 2)
+		value, ok := c.ContainerConfig.Labels[parts[0]]
+		if !ok || (len(parts) == 2 && value != parts[1]) {
+			return false
+		}
+	}
+	return true
+}
+
+// LoadError is a connector which cannot be loaded
+type LoadError struct {
+	Group string `json:"group"`
+	Name  string `json:"name"`
+	Error string `json:"error"`
+}
+
+// ConnectorList is a page of the connectors of a group
+type ConnectorList struct {
+	Connectors []Connector `json:"connectors"`
+	// Total is the number of connectors matching the filter
+	Total  int `json:"total"`
+	Offset int `json:"offset"`
+	Limit  int `json:"limit,omitempty"`
+	// Errors are the connectors of the group which cannot be loaded, and are neither filtered nor counted
+	Errors []LoadError `json:"errors,omitempty"`
+}
+
+// LoadConnectors returns the connectors of the group which can be loaded, and the errors of the others
+func LoadConnectors(group string) ([]Connector, []LoadError, error) {
+	names, err := GetConnectorNames(group)
+	if err != nil {
+		return nil, nil, err
+	}
+	connectors := make([]Connector, 0, len(names))
+	loadErrors := []LoadError{}
+	for _, name := range names {
+		conn, err := GetConnector(group, name)
+		if err != nil {
+			loadErrors = append(loadErrors, LoadError{Group: group, Name: name, Error: err.Error()})
+		} else {
+			connectors = append(connectors, *conn)
+		}
+	}
+	return connectors, loadErrors, nil
+}
+
+type listedConnector struct {
+	connector Connector
+	// last is the last execution of the connector, nil when it has not run or when it is not needed
+	last *executors.Executor
+}
+
+func (l listedConnector) status() string {
+	if l.last == nil {
+		return ""
+	}
+	return string(l.last.Status)
+}
+
+type listedConnectors struct {
+	connectors []listedConnector
+	field      string
+}
+
+func (l listedConnectors) Len() int { return len(l.connectors) }
+
+func (l listedConnectors) Swap(i, j int) {
+	l.connectors[i], l.connectors[j] = l.connectors[j], l.connectors[i]
+}
+
+func (l listedConnectors) Less(i, j int) bool {
+	a, b := l.connectors[i], l.connectors[j]
+	switch l.field {
+	case SortLastRun:
+		var ta, tb int64
+		if a.last != nil {
+			ta = a.last.CreatedAt.UnixNano()
+		}
+		if b.last != nil {
+			tb = b.last.CreatedAt.UnixNano()
+		}
+		if ta != tb {
+			return ta < tb
+		}
+	case SortStatus:
+		if a.status() != b.status() {
+			return a.status() < b.status()
+		}
+	}
+	return a.connector.Name < b.connector.Name
+}
+
+// ListConnectors returns the page of the connectors of the group matching the filter.
+// The connectors which have not run come first when sorted by last run or by status.
+func ListConnectors(group string, filter Filter, page listing.Page) (*ConnectorList, error) {
+	connectors, loadErrors, err := LoadConnectors(group)
+	if err != nil {
+		return nil, err
+	}
+	for _, loadError := range loadErrors {
+		log.WithField("error", loadError.Error).Warnf("Unable to load %s:%s", group, loadError.Name)
+	}
+
+	withLast := filter.Status != "" || page.Sort == SortLastRun || page.Sort == SortStatus
+	listed := listedConnectors{connectors: []listedConnector{}, field: page.Sort}
+	for i := range connectors {
+		if !filter.matches(&connectors[i]) {
+			continue
+		}
+		l := listedConnector{connector: connectors[i]}
+		if withLast {
+			l.last, err = Repository.GetLastExecutor(&connectors[i])
+			if err != nil && err != ErrNotFound {
+				return nil, err
+			}
+		}
+		if filter.Status != "" && l.status() != string(filter.Status) {
+			continue
+		}
+		listed.connectors = append(listed.connectors, l)
+	}
+	if page.Desc {
+		sort.Sort(sort.Reverse(listed))
+	} else {
+		sort.Sort(listed)
+	}
+
+	start, end := page.Bounds(len(listed.connectors))
+	list := &ConnectorList{
+		Connectors: make([]Connector, 0, end-start),
+		Total:      len(listed.connectors),
+		Offset:     page.Offset,
+		Limit:      page.Limit,
+		Errors:     loadErrors,
+	}
+	for _, l := range listed.connectors[start:end] {
+		list.Connectors = append(list.Connectors, l.connector)
+	}
+	return list, nil
+}
@@ -1,6 +1,8 @@
 package connectors
 
 import (
+	"crypto/sha1"
+	"encoding/hex"
 	"encoding/json"
 	"math/rand"
 	"time"
@@ -8,6 +10,7 @@ import (
 	log "github.com/Sirupsen/logrus"
 	"github.com/orcaman/concurrent-map"
 	"github.com/soprasteria/dockerapi"
+	"github.com/soprasteria/intools-engine/backends"
 )
 
 var Scheduler ConnectorScheduler
@@ -22,14 +25,24 @@ type Connector struct {
 	ContainerConfig *dockerapi.ContainerOptions `json:"config"`
 	Timeout         uint                        `json:"timeout,omitempty"`
 	Refresh         uint                        `json:"refresh,omitempty"`
+	Schema          map[string]interface{}      `json:"schema,omitempty"`
+	Artifacts       *ArtifactsConfig            `json:"artifacts,omitempty"`
+	Series          map[string]string           `json:"series,omitempty"`
+	Retention       *Retention                  `json:"retention,omitempty"`
+	// Managed is set on the connectors applied from manifests
+	Managed bool `json:"managed,omitempty"`
+	// Version is the version of the configuration, see ConfigVersion
+	Version int `json:"version,omitempty"`
 }
 
 type ConnectorScheduler struct {
 	connectorTickers cmap.ConcurrentMap
+	// connectorConfigs are the configurations run by the tickers, they can be updated without resetting the tickers
+	connectorConfigs cmap.ConcurrentMap
 }
 
 func NewConnectorScheduler() ConnectorScheduler {
-	return ConnectorScheduler{connectorTickers: cmap.New()}
+	return ConnectorScheduler{connectorTickers: cmap.New(), connectorConfigs: cmap.New()}
 }
 
 func (ct ConnectorScheduler) SetJob(conn *Connector) {
@@ -43,18 +56,31 @@ func (ct ConnectorScheduler) SetJob(conn *Connector) {
 		log.WithField("Group", conn.Group).WithField("Name", conn.Name).Info("Stopped old scheduling job for connector")
 	}
 
+	ct.connectorConfigs.Set(conn.Id(), conn)
 	newTicker := ct.newTicker(conn)
 	ct.connectorTickers.Set(conn.Id(), newTicker)
 
-	log.WithFields(log.Fields{
-		"Group":              conn.Group,
-		"Name":               conn.Name,
-		"Refresh in minutes": conn.Refresh,
-	}).Info("Connector is scheduled")
+	log.WithField("Group", conn.Group).WithField("Name", conn.Name).Info("Connector is scheduled")
 
 	log.Infof("There are %v connectors now scheduled", ct.connectorTickers.Count())
 }
 
+// UpdateJob replaces the configuration run by the job of the connector, without changing its schedule.
+// The connector is scheduled when it is not yet.
+func (ct ConnectorScheduler) UpdateJob(conn *Connector) {
+	if !ct.IsScheduled(conn) {
+		ct.SetJob(conn)
+		return
+	}
+	ct.connectorConfigs.Set(conn.Id(), conn)
+	log.WithField("Group", conn.Group).WithField("Name", conn.Name).Info("Updated configuration of scheduled connector")
+}
+
+// IsScheduled tells whether the connector is scheduled
+func (ct ConnectorScheduler) IsScheduled(conn *Connector) bool {
+	return ct.connectorTickers.Has(conn.Id())
+}
+
 func (ct ConnectorScheduler) RemoveJob(conn *Connector) {
 
 	log.WithField("Group", conn.Group).WithField("Name", conn.Name).Info("Removing scheduling of connector...")
@@ -62,6 +88,7 @@ func (ct ConnectorScheduler) RemoveJob(conn *Connector) {
 	if tmp, ok := ct.connectorTickers.Get(conn.Id()); ok {
 		oldTicker := tmp.(*time.Ticker)
 		ct.connectorTickers.Remove(conn.Id())
+		ct.connectorConfigs.Remove(conn.Id())
 		oldTicker.Stop()
 		log.WithField("Group", conn.Group).WithField("Name", conn.Name).Info("Stopped old scheduling job for connector")
 	} else {
@@ -72,6 +99,29 @@ func (ct ConnectorScheduler) RemoveJob(conn *Connector) {
 	log.Infof("There are %v connectors now scheduled", ct.connectorTickers.Count())
 }
 
+// current returns the configuration to run : the stored one, which may have been changed by the command line or by
+// another engine, or the one given to the scheduler when the store cannot be read. It returns false when the connector
+// has been removed from the store.
+func (ct ConnectorScheduler) current(conn *Connector) (*Connector, bool) {
+	exists, err := Repository.ConnectorExists(conn.Group, conn.Name)
+	if err == nil && !exists {
+		return nil, false
+	}
+	if err == nil {
+		var stored *Connector
+		stored, err = Repository.GetConnector(conn.Group, conn.Name)
+		if err == nil {
+			ct.connectorConfigs.Set(conn.Id(), stored)
+			return stored, true
+		}
+	}
+	log.WithError(err).Warnf("Cannot reload connector %s, running its last known configuration", conn.Id())
+	if tmp, ok := ct.connectorConfigs.Get(conn.Id()); ok {
+		return tmp.(*Connector), true
+	}
+	return conn, true
+}
+
 // getRandomizedRefreshTime generates a duration depending on following rules :
 // - From refreshInMinutes, get a random duration around -2m and +2m -> duration-2m < effective duration < duration+2m
 // - If effective duration is under 1m, set a default random duration between 1m and 5m
@@ -86,18 +136,31 @@ func getRandomizedRefreshTime(refreshInMinutes uint) time.Duration {
 
 func (ct ConnectorScheduler) newTicker(conn *Connector) *time.Ticker {
 
-	duration := getRandomizedRefreshTime(conn.Refresh)
+	// The refresh of the connector, or the one of its group when it inherits it
+	refresh := Effective(conn).Refresh
+	duration := getRandomizedRefreshTime(refresh)
 
 	ticker := time.NewTicker(duration)
 	log.WithFields(log.Fields{
 		"Group":              conn.Group,
 		"Name":               conn.Name,
-		"Refresh in minutes": conn.Refresh,
+		"Refresh in minutes": refresh,
 	}).Infof("Ticker will next be executed in %v", duration.String())
 
 	go func() {
 		for _ = range ticker.C {
-			Exec(conn)
+			current, ok := ct.current(conn)
+			if !ok {
+				log.WithField("Group", conn.Group).WithField("Name", conn.Name).Info("Connector removed from the store")
+				ct.RemoveJob(conn)
+				return
+			}
+			Exec(current)
+			if Effective(current).Refresh != refresh {
+				// The refresh has been changed out of this engine, by the command line or another engine
+				ct.SetJob(current)
+				return
+			}
 			log.WithField("Group", conn.Group).WithField("Name", conn.Name).Infof("Connector executed. Next execution in %s", duration.String())
 		}
 	}()
@@ -105,11 +168,28 @@ func (ct ConnectorScheduler) newTicker(conn *Connector) *time.Ticker {
 	return ticker
 }
 
+// Default timeout in seconds and refresh in minutes of connectors
+const (
+	DefaultTimeout = 15
+	DefaultRefresh = 300
+)
+
+// NewConnector returns a connector without timeout nor refresh, which inherits them from its group
 func NewConnector(group string, name string) *Connector {
-	conn := &Connector{group, name, nil, 15, 300}
+	conn := &Connector{Group: group, Name: name}
 	return conn
 }
 
+// SetDefaults sets the default timeout and refresh of the engine when they are not set
+func (c *Connector) SetDefaults() {
+	if c.Timeout == 0 {
+		c.Timeout = DefaultTimeout
+	}
+	if c.Refresh == 0 {
+		c.Refresh = DefaultRefresh
+	}
+}
+
 func (c *Connector) Init(image string, timeout uint, refresh uint, cmd []string) {
 	if c.ContainerConfig == nil {
 		c.ContainerConfig = &dockerapi.ContainerOptions{
@@ -131,6 +211,18 @@ func (c *Connector) GetContainerName() string {
 	return c.ContainerConfig.Name
 }
 
+// GetSpec returns the description of an execution of the connector for the backends
+func (c *Connector) GetSpec(engineId string, executionId string) *backends.Spec {
+	return &backends.Spec{
+		EngineId:    engineId,
+		ExecutionId: executionId,
+		Group:       c.Group,
+		Connector:   c.Name,
+		Options:     *c.ContainerConfig,
+		Timeout:     time.Duration(c.Timeout) * time.Second,
+	}
+}
+
 func (c *Connector) GetJSON() string {
 	b, err := json.Marshal(c)
 	if err != nil {
@@ -140,12 +232,18 @@ func (c *Connector) GetJSON() string {
 	return string(b[:])
 }
 
+// ETag identifies the current state of the connector for HTTP preconditions
+func (c *Connector) ETag() string {
+	hash := sha1.Sum([]byte(c.GetJSON()))
+	return "\"" + hex.EncodeToString(hash[:8]) + "\""
+}
+
 func (c *Connector) Id() string {
 	return c.Group + ":" + c.Name
 }
 
 func (c *Connector) Run() {
 	//TODO : Should not run error, or invalid connector ?
-	log.Debug("Run Connector %s:%s", c.Group, c.Name)
+	log.Debugf("Run Connector %s:%s", c.Group, c.Name)
 	Exec(c)
 }
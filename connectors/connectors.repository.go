@@ -0,0 +1,80 @@
+package connectors
+
+import (
+	"errors"
+	"time"
+
+	"github.com/soprasteria/intools-engine/executors"
+)
+
+// ErrNotFound is returned by repositories when an execution or a result does not exist
+var ErrNotFound = errors.New("not found")
+
+// ErrModified is returned by the conditional writes of repositories when the stored configuration of the connector
+// does not have the expected ETag
+var ErrModified = errors.New("modified")
+
+// Repository persists the connectors, their executions and their results, Redis by default
+var Repository ConnectorRepository = NewRedisConnectorRepository()
+
+// ConnectorRepository is the persistence of connectors, their executions, results and series
+type ConnectorRepository interface {
+	GetConnectors(group string) ([]string, error)
+	GetConnector(group string, connector string) (*Connector, error)
+	ConnectorExists(group string, connector string) (bool, error)
+	SaveConnector(c *Connector) error
+	RemoveConnector(c *Connector) error
+	// SaveConnectorIf saves the connector when its stored configuration has the ETag etag, or does not exist when
+	// etag is empty, and returns ErrModified otherwise. The check and the write are atomic.
+	SaveConnectorIf(c *Connector, etag string) error
+	// RemoveConfigIf removes the configuration of the connector under the same condition, its data is left to
+	// RemoveConnector
+	RemoveConfigIf(c *Connector, etag string) error
+
+	// SaveConnectorVersion saves the connector with a new version of its configuration, in one atomic write. The
+	// version is numbered after the last one, and the numbers of c and version are set to it. When etag is not nil,
+	// the write is conditioned as SaveConnectorIf, so that no number is allocated to a refused save.
+	SaveConnectorVersion(c *Connector, version *ConfigVersion, etag *string) error
+	// GetLastConfigVersion returns the most recent version of the configuration, ErrNotFound when there is none
+	GetLastConfigVersion(c *Connector) (*ConfigVersion, error)
+	// GetConfigVersions returns the configuration history of the connector, most recent first
+	GetConfigVersions(c *Connector) ([]*ConfigVersion, error)
+	GetConfigVersion(c *Connector, version int) (*ConfigVersion, error)
+
+	// SaveExecutor saves an execution in the history of the connector, as its last execution.
+	// The result of a valid execution becomes the last result of the connector.
+	SaveExecutor(c *Connector, exec *executors.Executor) error
+	GetLastExecutor(c *Connector) (*executors.Executor, error)
+	GetLastResult(c *Connector) (*map[string]interface{}, error)
+	GetExecution(c *Connector, id string) (*executors.Executor, error)
+	// GetExecutions returns the most recent executions, created before the execution with the given id when not empty
+	GetExecutions(c *Connector, limit int64, before string) ([]*executors.Executor, error)
+	// GetExpiredExecutions returns the ids of the executions beyond the size of the history, or older than its max age.
+	// Limits are ignored when zero.
+	GetExpiredExecutions(c *Connector, size int64, maxAge time.Duration) ([]string, error)
+	GetExecutionIds(c *Connector) ([]string, error)
+	// RemoveExecutions removes executions from the history, and returns them
+	RemoveExecutions(c *Connector, ids []string) ([]*executors.Executor, error)
+	// SaveLastResult replaces the last result of the connector, without execution
+	SaveLastResult(c *Connector, result *map[string]interface{}) error
+	RemoveLastResult(c *Connector) error
+
+	AddPoint(c *Connector, name string, point Point) error
+	GetPoints(c *Connector, name string, from time.Time, to time.Time) ([]Point, error)
+
+	// GetGroupRetention returns the retention of the connectors of a group, nil when not set
+	GetGroupRetention(group string) (*Retention, error)
+	// SaveGroupRetention sets the retention of the connectors of a group, nil removes it
+	SaveGroupRetention(group string, r *Retention) error
+	// GetGroupDefaults returns the defaults of the connectors of a group, nil when not set
+	GetGroupDefaults(group string) (*Defaults, error)
+	// SaveGroupDefaults sets the defaults of the connectors of a group, nil removes them
+	SaveGroupDefaults(group string, d *Defaults) error
+	// GetGroupVersion returns the version of the group, which changes with its connectors, its defaults and its
+	// retention, saved by the writes of the repository, and with BumpGroupVersion
+	GetGroupVersion(group string) (int64, error)
+	// BumpGroupVersion changes the version of the group, for what is saved in other stores
+	BumpGroupVersion(group string) error
+	// RemoveOrphans removes what is left of connectors which do not exist anymore, and returns the number of removed entries
+	RemoveOrphans() (int, error)
+}
@@ -0,0 +1,231 @@
+package connectors
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/soprasteria/intools-engine/executors"
+)
+
+// Retention limits what is kept of the executions and the results of connectors.
+// Limits set on a connector override the ones of its group, which override DefaultRetention. Zero values are not set,
+// Unlimited lifts an inherited limit.
+type Retention struct {
+	// MaxExecutions is the number of executions kept in the history
+	MaxExecutions int64 `json:"maxExecutions,omitempty"`
+	// MaxAge is the age in seconds after which executions are removed from the history
+	MaxAge int64 `json:"maxAge,omitempty"`
+	// MaxSize is the size in bytes of the history, executions with their logs and artifacts. The last execution is always kept.
+	MaxSize int64 `json:"maxSize,omitempty"`
+	// ResultTTL is the time in seconds after which the result of a connector which has not run since is removed
+	ResultTTL int64 `json:"resultTTL,omitempty"`
+}
+
+// Unlimited is the value of a limit which lifts the limit inherited from the group or the global retention
+const Unlimited = -1
+
+// DefaultRetention is the global retention, unlimited when zero
+var DefaultRetention = Retention{MaxExecutions: 50}
+
+// Number of executions read at once when measuring the size of the history
+const retentionPageSize = 100
+
+// CheckRetention validates the limits of a retention
+func CheckRetention(r *Retention) error {
+	if r == nil {
+		return nil
+	}
+	if r.MaxExecutions < Unlimited || r.MaxAge < Unlimited || r.MaxSize < Unlimited || r.ResultTTL < Unlimited {
+		return errors.New("retention limits must be positive, or -1 for unlimited")
+	}
+	return nil
+}
+
+// override returns the retention with the limits set in o
+func (r Retention) override(o *Retention) Retention {
+	if o == nil {
+		return r
+	}
+	if o.MaxExecutions != 0 {
+		r.MaxExecutions = o.MaxExecutions
+	}
+	if o.MaxAge != 0 {
+		r.MaxAge = o.MaxAge
+	}
+	if o.MaxSize != 0 {
+		r.MaxSize = o.MaxSize
+	}
+	if o.ResultTTL != 0 {
+		r.ResultTTL = o.ResultTTL
+	}
+	return r
+}
+
+// limits returns the retention with the lifted limits set to zero, which is unlimited
+func (r Retention) limits() Retention {
+	unset := func(limit *int64) {
+		if *limit < 0 {
+			*limit = 0
+		}
+	}
+	unset(&r.MaxExecutions)
+	unset(&r.MaxAge)
+	unset(&r.MaxSize)
+	unset(&r.ResultTTL)
+	return r
+}
+
+// GetGroupRetention returns the retention set on a group, nil when not set
+func GetGroupRetention(group string) (*Retention, error) {
+	return Repository.GetGroupRetention(group)
+}
+
+// SetGroupRetention sets the retention of the connectors of a group, nil removes it
+func SetGroupRetention(group string, r *Retention) error {
+	return Repository.SaveGroupRetention(group, r)
+}
+
+// GetRetention returns the retention applied to the connector, where zero limits are unlimited
+func GetRetention(c *Connector) Retention {
+	groupRetention, err := Repository.GetGroupRetention(c.Group)
+	if err != nil {
+		log.WithError(err).Warnf("Cannot load retention of group %s, using the global one", c.Group)
+	}
+	return DefaultRetention.override(groupRetention).override(c.Retention).limits()
+}
+
+// executionSize is the size of an execution in the store, with its artifacts
+func executionSize(executor *executors.Executor) int64 {
+	size := int64(len(executor.GetJSON()))
+	for _, artifact := range executor.Artifacts {
+		size += artifact.Size
+	}
+	return size
+}
+
+// getOversizedExecutions returns the ids of the oldest executions which do not fit in the size of the history.
+// The history is read by pages from the most recent execution, until its size is reached.
+func getOversizedExecutions(c *Connector, maxSize int64) ([]string, error) {
+	size := int64(0)
+	before := ""
+	for {
+		page, err := Repository.GetExecutions(c, retentionPageSize, before)
+		if err != nil {
+			return nil, err
+		}
+		for i, executor := range page {
+			size += executionSize(executor)
+			// The last execution is always kept
+			if size > maxSize && (before != "" || i > 0) {
+				return getExecutionsUntil(c, executor.Id)
+			}
+		}
+		if len(page) < retentionPageSize {
+			return []string{}, nil
+		}
+		before = page[len(page)-1].Id
+	}
+}
+
+// getExecutionsUntil returns the ids of the executions from the oldest one to the given one, included
+func getExecutionsUntil(c *Connector, id string) ([]string, error) {
+	ids, err := Repository.GetExecutionIds(c)
+	if err != nil {
+		return nil, err
+	}
+	for i := range ids {
+		if ids[i] == id {
+			return ids[:i+1], nil
+		}
+	}
+	return []string{}, nil
+}
+
+// trimExecutions removes the executions beyond the retention of the connector, with their artifacts.
+// It returns the removed executions.
+func trimExecutions(c *Connector, retention Retention) ([]*executors.Executor, error) {
+	ids, err := Repository.GetExpiredExecutions(c, retention.MaxExecutions, time.Duration(retention.MaxAge)*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	removed, err := removeExecutions(c, ids)
+	if err != nil || retention.MaxSize == 0 {
+		return removed, err
+	}
+
+	ids, err = getOversizedExecutions(c, retention.MaxSize)
+	if err != nil {
+		return removed, err
+	}
+	oversized, err := removeExecutions(c, ids)
+	return append(removed, oversized...), err
+}
+
+// expireResult removes the result of the connector when its last execution is older than the TTL of results.
+// It returns the removed result, nil when it is kept.
+func expireResult(c *Connector, retention Retention) (*map[string]interface{}, error) {
+	if retention.ResultTTL == 0 {
+		return nil, nil
+	}
+	last, err := Repository.GetLastExecutor(c)
+	if err == ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if time.Since(last.CreatedAt) < time.Duration(retention.ResultTTL)*time.Second {
+		return nil, nil
+	}
+	result, err := Repository.GetLastResult(c)
+	if err == ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return result, Repository.RemoveLastResult(c)
+}
+
+// CompactionReport tells what a compaction of the store reclaimed
+type CompactionReport struct {
+	StartedAt  time.Time `json:"startedAt"`
+	FinishedAt time.Time `json:"finishedAt"`
+	// Connectors is the number of connectors checked
+	Connectors int `json:"connectors"`
+	Executions int `json:"executions"`
+	Artifacts  int `json:"artifacts"`
+	Results    int `json:"results"`
+	// Orphans is the number of entries left by connectors which do not exist anymore
+	Orphans int `json:"orphans"`
+	// Bytes is the size of the removed executions, artifacts and results
+	Bytes int64 `json:"bytes"`
+}
+
+// Compact applies the retention of the connector, and adds what it removed to the report
+func Compact(c *Connector, report *CompactionReport) error {
+	retention := GetRetention(c)
+	report.Connectors++
+
+	executions, err := trimExecutions(c, retention)
+	for _, executor := range executions {
+		report.Executions++
+		report.Artifacts += len(executor.Artifacts)
+		report.Bytes += executionSize(executor)
+	}
+	if err != nil {
+		return err
+	}
+
+	result, err := expireResult(c, retention)
+	if err != nil || result == nil {
+		return err
+	}
+	b, _ := json.Marshal(result)
+	report.Results++
+	report.Bytes += int64(len(b))
+	log.WithFields(log.Fields{"group": c.Group, "connector": c.Name}).Info("Result of idle connector removed")
+	return nil
+}
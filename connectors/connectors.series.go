@@ -0,0 +1,113 @@
+package connectors
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/soprasteria/intools-engine/common/jsonpath"
+	"github.com/soprasteria/intools-engine/executors"
+)
+
+// Aggregations of the values of a bucket of a downsampled series
+const (
+	AggregateAvg = "avg"
+	AggregateMin = "min"
+	AggregateMax = "max"
+)
+
+// ErrUnknownSeries is returned when a connector does not declare the requested series
+var ErrUnknownSeries = errors.New("unknown series")
+
+// Point is the value of a series extracted from the result of an execution, at the creation time of the execution
+type Point struct {
+	Time  time.Time `json:"t"`
+	Value float64   `json:"v"`
+}
+
+// CheckSeries verifies the names and the JSONPath expressions of the series of a connector
+func CheckSeries(series map[string]string) error {
+	for name, expression := range series {
+		if name == "" {
+			return errors.New("series name cannot be empty")
+		}
+		if _, err := jsonpath.Compile(expression); err != nil {
+			return fmt.Errorf("series %s: %s", name, err.Error())
+		}
+	}
+	return nil
+}
+
+// extractSeries adds to the series of the connector the values extracted from the result of a valid execution
+func extractSeries(connector *Connector, executor *executors.Executor) {
+	if !executor.Valid || executor.JsonStdout == nil || len(connector.Series) == 0 {
+		return
+	}
+	document := map[string]interface{}(*executor.JsonStdout)
+	for name, expression := range connector.Series {
+		fields := log.Fields{"series": name, "execution": executor.Id}
+		path, err := jsonpath.Compile(expression)
+		if err != nil {
+			log.WithError(err).WithFields(fields).Warn("Invalid series path")
+			continue
+		}
+		value, err := path.GetNumber(document)
+		if err != nil {
+			log.WithError(err).WithFields(fields).Warn("Cannot extract series value")
+			continue
+		}
+		err = Repository.AddPoint(connector, name, Point{Time: executor.CreatedAt, Value: value})
+		if err != nil {
+			log.WithError(err).WithFields(fields).Error("Cannot save series value")
+		}
+	}
+}
+
+// GetSeries returns the values of a series of the connector between two dates. When step is set, the values are
+// downsampled in buckets of this duration, starting at from, using the aggregation.
+func GetSeries(c *Connector, name string, from time.Time, to time.Time, step time.Duration, aggregate string) ([]Point, error) {
+	if _, ok := c.Series[name]; !ok {
+		return nil, ErrUnknownSeries
+	}
+	points, err := Repository.GetPoints(c, name, from, to)
+	if err != nil {
+		return nil, err
+	}
+	if step <= 0 {
+		return points, nil
+	}
+	return Downsample(points, from, step, aggregate), nil
+}
+
+// Downsample aggregates the points by buckets of step duration starting at from. Empty buckets are omitted.
+func Downsample(points []Point, from time.Time, step time.Duration, aggregate string) []Point {
+	buckets := []Point{}
+	count := 0
+	for _, p := range points {
+		start := from.Add(p.Time.Sub(from) / step * step)
+		last := len(buckets) - 1
+		if last < 0 || !buckets[last].Time.Equal(start) {
+			if last >= 0 && aggregate == AggregateAvg {
+				buckets[last].Value /= float64(count)
+			}
+			buckets = append(buckets, Point{Time: start, Value: p.Value})
+			count = 1
+			continue
+		}
+		count++
+		switch aggregate {
+		case AggregateMin:
+			buckets[last].Value = math.Min(buckets[last].Value, p.Value)
+		case AggregateMax:
+			buckets[last].Value = math.Max(buckets[last].Value, p.Value)
+		default:
+			buckets[last].Value += p.Value
+		}
+	}
+	if len(buckets) > 0 && aggregate == AggregateAvg {
+		buckets[len(buckets)-1].Value /= float64(count)
+	}
+	return buckets
+}
@@ -2,168 +2,115 @@ package connectors
 
 import (
 	"bytes"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"sync"
-	"time"
 
 	log "github.com/Sirupsen/logrus"
-	"github.com/fsouza/go-dockerclient"
 	"github.com/soprasteria/intools-engine/common/websocket"
 	"github.com/soprasteria/intools-engine/executors"
 	"github.com/soprasteria/intools-engine/intools"
 )
 
+// Exec runs the connector once. The execution is saved whatever its outcome, an error is returned
+// only when the engine failed to run the connector.
 func Exec(connector *Connector) (*executors.Executor, error) {
-	executor := &executors.Executor{}
+	executor := executors.NewExecutor()
+	executor.ConfigVersion = connector.Version
 
-	//Saving connector to redis
-	go SaveConnector(connector)
+	// Queued until its container is started
+	SaveExecutor(connector, executor)
 
-	//Get all containers
-	simpleContainers, err := intools.Engine.GetDockerClient().ListContainers()
+	err := run(Effective(connector), executor)
 	if err != nil {
-		log.Error(err)
-		return nil, err
+		log.WithError(err).WithField("execution", executor.Id).Errorf("Execution of connector %s failed", connector.Id())
+		executor.Fail(err)
 	}
 
-	//Searching for the container with the same name
-	containerExists := false
-	previousContainerID := "-1"
-	for _, c := range simpleContainers.GetAll() {
-		if c.Name() == fmt.Sprintf("/%s", connector.GetContainerName()) {
-			containerExists = true
-			previousContainerID = c.ID()
+	// Broadcast result to registered clients, only when it can be rendered
+	if executor.Valid {
+		lightConnector := &websocket.LightConnector{
+			GroupId:     connector.Group,
+			ConnectorId: connector.Name,
+			Value:       executor.JsonStdout,
 		}
+		websocket.ConnectorBuffer <- lightConnector
 	}
 
-	//If it exists, remove it
-	if containerExists {
-		log.Infof("Removing container %s [/%s]", previousContainerID[:11], connector.GetContainerName())
-		removeContainerOptions := docker.RemoveContainerOptions{ID: previousContainerID, RemoveVolumes: true, Force: true}
-		err = intools.Engine.GetDockerClient().Docker.RemoveContainer(removeContainerOptions)
-		if err != nil {
-			log.Error("Cannot remove container " + previousContainerID[:11])
-			log.Error(err)
-			return nil, err
-		}
+	//Save result to redis
+	SaveExecutor(connector, executor)
+	TrimExecutions(connector)
+	extractSeries(connector, executor)
+
+	return executor, err
+}
+
+// run creates the container of the connector, waits for its end and reads its output
+func run(connector *Connector, executor *executors.Executor) error {
+	if connector.ContainerConfig == nil {
+		return errors.New("connector has no container configuration")
 	}
 
-	//Create container
-	log.Debug("New container with config ", connector.ContainerConfig)
-	container, err := intools.Engine.GetDockerClient().NewContainer(*connector.ContainerConfig)
+	backend := intools.Engine.GetBackend()
+	spec := connector.GetSpec(intools.Engine.GetEngineId(), executor.Id)
+
+	//Create the container, replacing the previous one if needed
+	instance, err := backend.Create(spec)
 	if err != nil {
-		log.Error("Cannot create container " + connector.GetContainerName())
-		log.Error(err)
-		return nil, err
+		return fmt.Errorf("cannot create container %s: %s", connector.GetContainerName(), err.Error())
 	}
-	//Save the short ContainerId
-	executor.Host = intools.Engine.GetDockerHost()
+	executor.Host = instance.Host
+	defer func() {
+		err := backend.Remove(instance)
+		if err != nil {
+			log.WithError(err).Warn("Cannot remove container " + instance.Name + ", it will be removed by the janitor")
+		}
+	}()
 
 	// Starting container
-	log.Info("Starting container " + connector.GetContainerName())
-	// we don't want to force pulling of images in order to support projects which don't have a registry because images are only local in that case
-	forcePull := false
-	err = container.Run(forcePull)
+	err = backend.Start(instance)
 	if err != nil {
-		log.Error("Cannot start container " + connector.GetContainerName())
-		log.Error(err)
-		return nil, err
+		return fmt.Errorf("cannot start container %s: %s", connector.GetContainerName(), err.Error())
 	}
+	executor.Start()
 
-	//Prepare the waiting group to sync execution of the container
-	var wg sync.WaitGroup
-	wg.Add(1)
-
-	executor.ContainerId = container.ID()[:11]
+	//Save the short ContainerId
+	executor.ContainerId = instance.ID
+	if len(executor.ContainerId) > 11 {
+		executor.ContainerId = executor.ContainerId[:11]
+	}
+	SaveExecutor(connector, executor)
 	log.WithField("containerId", executor.ContainerId).WithField("containerName", connector.GetContainerName()).Info("Container successfully started")
 	log.Debug(executor.ContainerId + " will be stopped after " + fmt.Sprint(connector.Timeout) + " seconds")
-	//Trigger stop of the container after the timeout
-	intools.Engine.GetDockerClient().Docker.StopContainer(container.ID(), connector.Timeout)
 
-	//Wait for the end of the execution of the container
-	for {
-		//Each time inspect the container
-		inspect, err := intools.Engine.GetDockerClient().InspectContainer(container.ID())
-		if err != nil {
-			log.Error("Cannot inspect container " + connector.GetContainerName())
-			log.Error(err)
-			return executor, err
-		}
-		if !inspect.IsRunning() {
-			//When the container is not running
-			log.Debug(connector.GetContainerName() + " is stopped")
-			executor.Running = false
-			executor.Terminated = true
-			executor.ExitCode = inspect.Container.State.ExitCode
-			executor.StartedAt = inspect.Container.State.StartedAt
-			executor.FinishedAt = inspect.Container.State.FinishedAt
-			//Trigger next part of the waiting group
-			wg.Done()
-			//Exit from the waiting loop
-			break
-		} else {
-			//Wait
-			log.Debug(connector.GetContainerName() + " is running...")
-			time.Sleep(5 * time.Second)
-		}
+	//Wait for the end of the execution of the container, stopping it after the timeout
+	state, err := backend.Wait(instance, spec.Timeout)
+	if err != nil {
+		return fmt.Errorf("cannot wait for container %s: %s", connector.GetContainerName(), err.Error())
 	}
-
-	//Next part : after the container has been executed
-	wg.Wait()
+	executor.StartedAt = state.StartedAt
+	executor.FinishedAt = state.FinishedAt
 
 	stdoutBuf := new(bytes.Buffer)
 	stderrBuf := new(bytes.Buffer)
-	logOptions := docker.LogsOptions{
-		Container:    container.ID(),
-		OutputStream: stdoutBuf,
-		ErrorStream:  stderrBuf,
-		Stdout:       true,
-		Stderr:       true,
-		Tail:         "all",
-		Follow:       true,
-		Timestamps:   false,
-	}
 
 	//Get the stdout and stderr
-	err = intools.Engine.GetDockerClient().Docker.Logs(logOptions)
-
-	if err != nil {
-		log.Error("-cannot read stdout logs from server")
-	} else {
-		containerLogs := stdoutBuf.String()
-		log.Debugf("container logs %s", containerLogs)
-		executor.Stdout = containerLogs
-		executor.JsonStdout = new(map[string]interface{})
-		errJSONStdOut := json.Unmarshal(stdoutBuf.Bytes(), executor.JsonStdout)
-		executor.Valid = true
-
-		if errJSONStdOut != nil {
-			log.Warnf("Unable to parse stdout from container %s", container.Name())
-			log.Warnf("Error: %s - Stdout: %s", errJSONStdOut, containerLogs)
-		}
-
-		executor.Stderr = stderrBuf.String()
-	}
-
-	removeVolumes := false
-	err = container.Remove(removeVolumes)
+	err = backend.Logs(instance, stdoutBuf, stderrBuf)
 	if err != nil {
-		log.Error("Cannot remove container " + container.Name())
-		log.Error(err)
-		return nil, err
+		return fmt.Errorf("cannot read logs of container %s: %s", connector.GetContainerName(), err.Error())
 	}
 
-	// Broadcast result to registered clients
-	lightConnector := &websocket.LightConnector{
-		GroupId:     connector.Group,
-		ConnectorId: connector.Name,
-		Value:       executor.JsonStdout,
-	}
-	websocket.ConnectorBuffer <- lightConnector
+	collectArtifacts(connector, executor, backend, instance)
 
-	//Save result to redis
-	defer SaveExecutor(connector, executor)
+	containerLogs := stdoutBuf.String()
+	log.Debugf("container logs %s", containerLogs)
+	executor.Stdout = containerLogs
+	executor.Stderr = stderrBuf.String()
+	executor.ValidateStdout(stdoutBuf.Bytes(), connector.Schema)
+	executor.Finish(state.ExitCode, state.TimedOut)
 
-	return executor, nil
+	if !executor.Valid {
+		log.WithFields(log.Fields{"status": executor.Status, "validation": executor.Validation}).Warnf("Execution of container %s is not valid: %s", instance.Name, executor.Error)
+		log.Warnf("Stdout: %s", containerLogs)
+	}
+	return nil
 }
@@ -0,0 +1,75 @@
+package connectors
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/soprasteria/intools-engine/common/jsonschema"
+)
+
+// Bounds of the timeout, in seconds, and of the refresh, in minutes, of connectors
+const (
+	MinTimeout = 1
+	MaxTimeout = 3600
+	MinRefresh = 1
+	MaxRefresh = 7 * 24 * 60
+)
+
+// validName is the charset of the names of groups and connectors, which are part of keys and URLs
+var validName = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]{0,63}$`)
+
+// FieldError is an invalid field of a connector
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationErrors are the invalid fields of a connector
+type ValidationErrors []FieldError
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, fieldError := range e {
+		messages[i] = fieldError.Field + " : " + fieldError.Message
+	}
+	return strings.Join(messages, ", ")
+}
+
+// CheckName validates the name of a group or a connector
+func CheckName(name string) error {
+	if !validName.MatchString(name) {
+		return fmt.Errorf("'%s' must be 1 to 64 letters, digits, '_', '.' or '-', starting with a letter or a digit", name)
+	}
+	return nil
+}
+
+// Validate checks the configuration of a connector, and returns its invalid fields, nil when it is valid
+func Validate(c *Connector) ValidationErrors {
+	errors := ValidationErrors{}
+	add := func(field string, err error) {
+		if err != nil {
+			errors = append(errors, FieldError{Field: field, Message: err.Error()})
+		}
+	}
+
+	add("group", CheckName(c.Group))
+	add("name", CheckName(c.Name))
+	if c.ContainerConfig == nil {
+		add("config", fmt.Errorf("is required"))
+	} else if strings.TrimSpace(c.ContainerConfig.Image) == "" {
+		add("config.Image", fmt.Errorf("is required"))
+	}
+	add("timeout", checkTimeout(c.Timeout))
+	add("refresh", checkRefresh(c.Refresh))
+	if c.Schema != nil {
+		add("schema", jsonschema.Check(c.Schema))
+	}
+	add("series", CheckSeries(c.Series))
+	add("retention", CheckRetention(c.Retention))
+
+	if len(errors) == 0 {
+		return nil
+	}
+	return errors
+}
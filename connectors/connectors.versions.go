@@ -0,0 +1,176 @@
+package connectors
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// ConfigVersion is a version of the configuration of a connector
+type ConfigVersion struct {
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"createdAt"`
+	// Author is the user who saved the version, when the engine knows it
+	Author string `json:"author,omitempty"`
+	// RolledBackFrom is the version restored by a rollback
+	RolledBackFrom int        `json:"rolledBackFrom,omitempty"`
+	Connector      *Connector `json:"connector"`
+}
+
+// Difference is a value of a configuration which differs between two versions, nil when missing
+type Difference struct {
+	Path string      `json:"path"`
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// SameConfig tells whether two connectors have the same configuration, whatever their versions
+func SameConfig(a *Connector, b *Connector) bool {
+	ca, cb := *a, *b
+	ca.Version, cb.Version = 0, 0
+	return ca.GetJSON() == cb.GetJSON()
+}
+
+// SaveConnectorVersion saves the connector, with a new version of its configuration when it changed.
+// The version of the connector is set to the saved one. It returns whether a version was created.
+func SaveConnectorVersion(c *Connector, author string) (bool, error) {
+	return saveConnectorVersion(c, author, 0, nil)
+}
+
+// SaveConnectorVersionIf is SaveConnectorVersion done only when the stored configuration of the connector has the
+// ETag etag, or does not exist when etag is empty. It returns ErrModified otherwise.
+func SaveConnectorVersionIf(c *Connector, author string, etag string) (bool, error) {
+	return saveConnectorVersion(c, author, 0, &etag)
+}
+
+// setVersion numbers a new version of the configuration of the connector, used by the repositories when they
+// allocate the number
+func setVersion(c *Connector, version *ConfigVersion, number int) {
+	c.Version = number
+	version.Version = number
+	version.Connector = copyConnector(c)
+}
+
+// saveConnectorVersion saves the connector, conditioned by etag when it is not nil. A new version is numbered by
+// the repository in the same write as the configuration, so that a refused save leaves no gap in the numbers.
+func saveConnectorVersion(c *Connector, author string, rolledBackFrom int, etag *string) (bool, error) {
+	last, err := Repository.GetLastConfigVersion(c)
+	if err != nil && err != ErrNotFound {
+		return false, err
+	}
+	if last != nil && SameConfig(last.Connector, c) {
+		c.Version = last.Version
+		if etag == nil {
+			return false, Repository.SaveConnector(c)
+		}
+		return false, Repository.SaveConnectorIf(c, *etag)
+	}
+
+	version := &ConfigVersion{
+		CreatedAt:      time.Now().UTC(),
+		Author:         author,
+		RolledBackFrom: rolledBackFrom,
+	}
+	err = Repository.SaveConnectorVersion(c, version, etag)
+	if err != nil {
+		return false, err
+	}
+	log.WithFields(log.Fields{"group": c.Group, "connector": c.Name, "version": c.Version, "author": author}).Info("New version of connector configuration")
+	return true, nil
+}
+
+// GetConfigVersions returns the versions of the configuration of the connector, most recent first
+func GetConfigVersions(c *Connector) ([]*ConfigVersion, error) {
+	return Repository.GetConfigVersions(c)
+}
+
+// GetConfigVersion returns a version of the configuration of the connector
+func GetConfigVersion(c *Connector, version int) (*ConfigVersion, error) {
+	return Repository.GetConfigVersion(c, version)
+}
+
+// Rollback saves an earlier version of the configuration as the new version of the connector, and returns the connector
+func Rollback(c *Connector, version int, author string) (*Connector, error) {
+	return rollback(c, version, author, nil)
+}
+
+// RollbackIf is Rollback done only when the stored configuration of the connector has the ETag etag. It returns
+// ErrModified otherwise.
+func RollbackIf(c *Connector, version int, author string, etag string) (*Connector, error) {
+	return rollback(c, version, author, &etag)
+}
+
+func rollback(c *Connector, version int, author string, etag *string) (*Connector, error) {
+	previous, err := Repository.GetConfigVersion(c, version)
+	if err != nil {
+		return nil, err
+	}
+	conn := *previous.Connector
+	_, err = saveConnectorVersion(&conn, author, version, etag)
+	if err != nil {
+		return nil, err
+	}
+	return &conn, nil
+}
+
+// DiffConfigs returns the values which differ between two configurations, with their JSON path
+func DiffConfigs(from *Connector, to *Connector) ([]Difference, error) {
+	var a, b interface{}
+	if err := remarshal(from, &a); err != nil {
+		return nil, err
+	}
+	if err := remarshal(to, &b); err != nil {
+		return nil, err
+	}
+	differences := []Difference{}
+	diffValues("$", a, b, &differences)
+	return differences, nil
+}
+
+func remarshal(c *Connector, v *interface{}) error {
+	copied := *c
+	copied.Version = 0
+	b, err := json.Marshal(copied)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+func diffValues(path string, a interface{}, b interface{}, differences *[]Difference) {
+	ma, aIsMap := a.(map[string]interface{})
+	mb, bIsMap := b.(map[string]interface{})
+	if aIsMap && bIsMap {
+		keys := []string{}
+		for k := range ma {
+			keys = append(keys, k)
+		}
+		for k := range mb {
+			if _, ok := ma[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			diffValues(path+"."+k, ma[k], mb[k], differences)
+		}
+		return
+	}
+
+	la, aIsList := a.([]interface{})
+	lb, bIsList := b.([]interface{})
+	if aIsList && bIsList && len(la) == len(lb) {
+		for i := range la {
+			diffValues(fmt.Sprintf("%s[%d]", path, i), la[i], lb[i], differences)
+		}
+		return
+	}
+
+	if !reflect.DeepEqual(a, b) {
+		*differences = append(*differences, Difference{Path: path, From: a, To: b})
+	}
+}
@@ -0,0 +1,31 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/soprasteria/intools-engine/openapi"
+)
+
+func ControllerGetOpenAPI(c *gin.Context) {
+	c.Data(http.StatusOK, "application/json; charset=utf-8", []byte(openapi.Spec))
+}
+
+const deprecatedKey = "deprecated"
+
+// Deprecated marks the responses of the routes without prefix, kept for the clients of the unversioned API,
+// with a link to the same route under the prefix
+func Deprecated(prefix string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(deprecatedKey, true)
+		c.Header("Deprecation", "true")
+		c.Header("Link", "<"+prefix+c.Request.URL.Path+">; rel=\"successor-version\"")
+		c.Next()
+	}
+}
+
+// isDeprecated tells whether the request uses a route of the unversioned API, whose responses are kept as they were
+func isDeprecated(c *gin.Context) bool {
+	_, ok := c.Get(deprecatedKey)
+	return ok
+}
@@ -0,0 +1,64 @@
+package controllers
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/soprasteria/intools-engine/bundles"
+)
+
+func ControllerExport(c *gin.Context) {
+	format := c.DefaultQuery("format", bundles.FormatJSON)
+	if format != bundles.FormatJSON && format != bundles.FormatYAML {
+		abortWithError(c, http.StatusBadRequest, "Invalid format", errors.New("format must be json or yaml"))
+		return
+	}
+
+	b, err := bundles.Export(c.Request.URL.Query()["group"], c.Query("results") == "true")
+	if notFound, ok := err.(*bundles.GroupNotFoundError); ok {
+		abortNotFound(c, "Group %s not found", notFound.Group)
+		return
+	} else if err != nil {
+		abortInternalError(c, err)
+		return
+	}
+	content, err := bundles.Encode(b, format)
+	if err != nil {
+		abortInternalError(c, err)
+		return
+	}
+	contentType := "application/json"
+	if format == bundles.FormatYAML {
+		contentType = "application/x-yaml"
+	}
+	c.Header("Content-Disposition", "attachment; filename=\"intools-bundle."+format+"\"")
+	c.Data(http.StatusOK, contentType, content)
+}
+
+func ControllerImport(c *gin.Context) {
+	content, err := ioutil.ReadAll(c.Request.Body)
+	if err != nil {
+		abortWithError(c, http.StatusBadRequest, "Invalid bundle", err)
+		return
+	}
+	b, err := bundles.Decode(content)
+	if err != nil {
+		abortWithError(c, http.StatusBadRequest, "Invalid bundle", err)
+		return
+	}
+
+	report, err := bundles.Import(b, bundles.ImportOptions{
+		Mode:     c.DefaultQuery("mode", bundles.ModeMerge),
+		DryRun:   c.Query("dryRun") == "true",
+		Schedule: true,
+	})
+	if err != nil && report == nil {
+		abortWithError(c, http.StatusBadRequest, "Invalid bundle", err)
+	} else if err != nil {
+		abortWithError(c, http.StatusInternalServerError, "Import failed", err)
+	} else {
+		c.JSON(http.StatusOK, report)
+	}
+}
@@ -1,123 +1,586 @@
 package controllers
 
 import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"mime"
 	"net/http"
+	"strconv"
+	"time"
 
 	log "github.com/Sirupsen/logrus"
 	"github.com/gin-gonic/gin"
+	"github.com/soprasteria/intools-engine/artifacts"
+	"github.com/soprasteria/intools-engine/common/jsonmerge"
 	"github.com/soprasteria/intools-engine/connectors"
+	"github.com/soprasteria/intools-engine/executors"
+	"github.com/soprasteria/intools-engine/groups"
 )
 
 func ControllerGetConnectors(c *gin.Context) {
 	group := c.Param("group")
-	connectors := connectors.GetConnectors(group)
-	c.JSON(http.StatusOK, connectors)
+	exists, err := groups.Repository.GroupExists(group)
+	if err != nil {
+		abortInternalError(c, err)
+		return
+	}
+	if !exists {
+		abortNotFound(c, "Group %s not found", group)
+		return
+	}
+	page, ok := readPage(c, connectors.SortName, connectors.SortLastRun, connectors.SortStatus)
+	if !ok {
+		return
+	}
+	filter := connectors.Filter{
+		Prefix: c.Query("prefix"),
+		Image:  c.Query("image"),
+		Status: executors.Status(c.Query("status")),
+		Labels: c.Request.URL.Query()["label"],
+	}
+	if err := connectors.CheckFilter(filter); err != nil {
+		abortWithError(c, http.StatusBadRequest, "Invalid filter", err)
+		return
+	}
+
+	list, err := connectors.ListConnectors(group, filter, page)
+	if err != nil {
+		abortInternalError(c, err)
+		return
+	}
+	setTotalCount(c, list.Total)
+	if isDeprecated(c) {
+		c.JSON(http.StatusOK, list.Connectors)
+	} else {
+		c.JSON(http.StatusOK, list)
+	}
 }
 
-func ControllerGetConnector(c *gin.Context) {
+// getConnector loads the connector of the request, and answers and returns nil when it does not exist
+func getConnector(c *gin.Context) *connectors.Connector {
 	group := c.Param("group")
 	connector := c.Param("connector")
 
 	log.Debugf("Searching for %s:%s", group, connector)
 
-	conn, err := connectors.GetConnector(group, connector)
+	conn, err := loadConnector(group, connector)
 	if err != nil {
-		c.String(http.StatusNotFound, err.Error())
-	} else {
+		abortInternalError(c, err)
+		return nil
+	}
+	if conn == nil {
+		abortNotFound(c, "Connector %s:%s not found", group, connector)
+	}
+	return conn
+}
+
+func ControllerGetConnector(c *gin.Context) {
+	conn := getConnector(c)
+	if conn != nil && !notModified(c, conn.ETag()) {
 		c.JSON(http.StatusOK, conn)
 	}
 }
 
 func ControllerExecConnector(c *gin.Context) {
-	group := c.Param("group")
-	connector := c.Param("connector")
+	conn := getConnector(c)
+	if conn == nil {
+		return
+	}
+	executor, err := connectors.Exec(conn)
+	if err != nil {
+		abortInternalError(c, err)
+	} else {
+		c.JSON(http.StatusOK, executor)
+	}
+}
 
-	log.Debugf("Searching for %s:%s", group, connector)
+func ControllerGetConnectorExecutor(c *gin.Context) {
+	conn := getConnector(c)
+	if conn == nil {
+		return
+	}
+	exec := connectors.GetLastConnectorExecutor(conn)
+	if exec == nil {
+		abortNotFound(c, "No executor found for %s:%s", conn.Group, conn.Name)
+	} else {
+		c.JSON(http.StatusOK, exec)
+	}
+}
 
-	conn, err := connectors.GetConnector(group, connector)
-	if err != nil {
-		c.String(http.StatusNotFound, err.Error())
+func ControllerGetConnectorResult(c *gin.Context) {
+	conn := getConnector(c)
+	if conn == nil {
+		return
+	}
+	result := connectors.GetLastConnectorResult(conn)
+	if result == nil {
+		abortNotFound(c, "No valid result found for %s:%s", conn.Group, conn.Name)
 	} else {
-		executor, err := connectors.Exec(conn)
-		if err != nil {
-			c.String(http.StatusInternalServerError, err.Error())
-		} else {
-			c.JSON(http.StatusOK, executor)
+		c.JSON(http.StatusOK, result)
+	}
+}
+
+func ControllerGetConnectorStatus(c *gin.Context) {
+	conn := getConnector(c)
+	if conn == nil {
+		return
+	}
+	exec := connectors.GetLastConnectorExecutor(conn)
+	if exec == nil {
+		abortNotFound(c, "No executor found for %s:%s", conn.Group, conn.Name)
+	} else {
+		c.JSON(http.StatusOK, gin.H{
+			"id":     exec.Id,
+			"status": exec.Status,
+			"error":  exec.Error,
+		})
+	}
+}
+
+const (
+	defaultExecutionsLimit = 20
+	maxExecutionsLimit     = 100
+)
+
+func ControllerGetConnectorExecutions(c *gin.Context) {
+	conn := getConnector(c)
+	if conn == nil {
+		return
+	}
+
+	limit := int64(defaultExecutionsLimit)
+	if sLimit := c.Query("limit"); sLimit != "" {
+		var err error
+		limit, err = strconv.ParseInt(sLimit, 10, 64)
+		if err != nil || limit <= 0 || limit > maxExecutionsLimit {
+			err = fmt.Errorf("limit must be between 1 and %d", maxExecutionsLimit)
+			abortWithError(c, http.StatusBadRequest, "Invalid limit", err)
+			return
 		}
 	}
+
+	executions, err := connectors.GetConnectorExecutions(conn, limit, c.Query("before"))
+	if err == connectors.ErrNotFound {
+		abortNotFound(c, "Execution %s not found", c.Query("before"))
+	} else if err != nil {
+		abortInternalError(c, err)
+	} else {
+		c.JSON(http.StatusOK, executions)
+	}
 }
 
-func ControllerGetConnectorExecutor(c *gin.Context) {
-	group := c.Param("group")
-	connector := c.Param("connector")
-	conn, err := connectors.GetConnector(group, connector)
+func ControllerGetConnectorExecution(c *gin.Context) {
+	conn := getConnector(c)
+	if conn == nil {
+		return
+	}
+	executor, err := connectors.GetConnectorExecution(conn, c.Param("id"))
+	if err == connectors.ErrNotFound {
+		abortNotFound(c, "Execution %s not found", c.Param("id"))
+	} else if err != nil {
+		abortInternalError(c, err)
+	} else {
+		c.JSON(http.StatusOK, executor)
+	}
+}
+
+const defaultSeriesRange = 24 * time.Hour
+
+// parseSeriesTime reads a date given as RFC 3339 or as a Unix timestamp in seconds
+func parseSeriesTime(value string, defaultTime time.Time) (time.Time, error) {
+	if value == "" {
+		return defaultTime, nil
+	}
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(seconds, 0), nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+func ControllerGetConnectorSeries(c *gin.Context) {
+	conn := getConnector(c)
+	if conn == nil {
+		return
+	}
+
+	to, err := parseSeriesTime(c.Query("to"), time.Now())
+	if err != nil {
+		abortWithError(c, http.StatusBadRequest, "Invalid to date", err)
+		return
+	}
+	from, err := parseSeriesTime(c.Query("from"), to.Add(-defaultSeriesRange))
 	if err != nil {
-		c.String(http.StatusNotFound, err.Error())
+		abortWithError(c, http.StatusBadRequest, "Invalid from date", err)
+		return
+	}
+	var step time.Duration
+	if sStep := c.Query("step"); sStep != "" {
+		step, err = time.ParseDuration(sStep)
+		if err != nil || step <= 0 {
+			abortWithError(c, http.StatusBadRequest, "Invalid step", fmt.Errorf("step must be a positive duration, e.g. 1h"))
+			return
+		}
+	}
+	aggregate := c.DefaultQuery("aggregate", connectors.AggregateAvg)
+	if aggregate != connectors.AggregateAvg && aggregate != connectors.AggregateMin && aggregate != connectors.AggregateMax {
+		abortWithError(c, http.StatusBadRequest, "Invalid aggregate", fmt.Errorf("aggregate must be avg, min or max"))
+		return
+	}
+
+	points, err := connectors.GetSeries(conn, c.Param("name"), from, to, step, aggregate)
+	if err == connectors.ErrUnknownSeries {
+		abortNotFound(c, "Unknown series %s", c.Param("name"))
+	} else if err != nil {
+		abortInternalError(c, err)
 	} else {
-		exec := connectors.GetLastConnectorExecutor(conn)
-		if exec == nil {
-			c.String(http.StatusNotFound, "no executor found")
-		} else {
-			c.JSON(http.StatusOK, exec)
+		c.JSON(http.StatusOK, points)
+	}
+}
+
+func ControllerGetConnectorArtifact(c *gin.Context) {
+	conn := getConnector(c)
+	if conn == nil {
+		return
+	}
+	content, contentType, err := connectors.GetArtifact(conn, c.Param("id"), c.Param("name"))
+	if err == artifacts.ErrNotFound {
+		abortNotFound(c, "Artifact %s not found", c.Param("name"))
+	} else if err != nil {
+		abortInternalError(c, err)
+	} else {
+		// The name is quoted, or encoded when it is not ASCII
+		disposition := mime.FormatMediaType("attachment", map[string]string{"filename": c.Param("name")})
+		if disposition == "" {
+			disposition = "attachment"
 		}
+		c.Header("Content-Disposition", disposition)
+		c.Data(http.StatusOK, contentType, content)
 	}
 }
 
-func ControllerGetConnectorResult(c *gin.Context) {
-	group := c.Param("group")
-	connector := c.Param("connector")
-	conn, err := connectors.GetConnector(group, connector)
+// loadConnector returns the connector, nil when it does not exist
+func loadConnector(group string, connector string) (*connectors.Connector, error) {
+	exists, err := connectors.Repository.ConnectorExists(group, connector)
+	if err != nil || !exists {
+		return nil, err
+	}
+	return connectors.GetConnector(group, connector)
+}
+
+// readConnector decodes the connector of the body of the request over a new connector.
+// It answers 400 Bad Request and returns nil when the body is not a connector.
+func readConnector(c *gin.Context) *connectors.Connector {
+	conn := connectors.NewConnector(c.Param("group"), c.Param("connector"))
+	err := json.NewDecoder(c.Request.Body).Decode(conn)
 	if err != nil {
-		c.String(http.StatusNotFound, err.Error())
-	} else {
-		exec := connectors.GetLastConnectorExecutor(conn)
-		if exec == nil {
-			c.String(http.StatusNotFound, "no result found")
-		} else {
-			if exec.Valid {
-				c.JSON(http.StatusOK, exec.JsonStdout)
-			} else {
-				c.String(http.StatusNotFound, "invalid result")
-			}
-		}
+		abortWithError(c, http.StatusBadRequest, "Invalid connector", err)
+		return nil
 	}
+	conn.Group = c.Param("group")
+	conn.Name = c.Param("connector")
+	return conn
 }
 
-func ControllerCreateConnector(c *gin.Context) {
-	group := c.Param("group")
-	connector := c.Param("connector")
+func invalidConnector(c *gin.Context, errs connectors.ValidationErrors) {
+	abort(c, http.StatusBadRequest, APIError{
+		Code:    CodeInvalidConnector,
+		Message: "Invalid connector",
+		Details: errs.Error(),
+		Errors:  errs,
+	})
+}
 
-	var conn connectors.Connector
-	c.Bind(&conn)
-	conn.Group = group
-	conn.Name = connector
+// saveConnector validates and saves the connector, and reschedules it only when it is created or its refresh changes.
+// It returns whether its configuration changed, and answers and returns false when it cannot be saved.
+func saveConnector(c *gin.Context, conn *connectors.Connector, previous *connectors.Connector) (bool, bool) {
+	if errs := connectors.Validate(conn); errs != nil {
+		invalidConnector(c, errs)
+		return false, false
+	}
 
-	// Save Connector into Redis
-	connectors.SaveConnector(&conn)
+	// Save Connector into Redis, with a new version of its configuration when it changed
+	var changed bool
+	var err error
+	if hasPreconditions(c) {
+		changed, err = connectors.SaveConnectorVersionIf(conn, getAuthor(c), etagOf(previous))
+	} else {
+		changed, err = connectors.SaveConnectorVersion(conn, getAuthor(c))
+	}
+	if err == connectors.ErrModified {
+		abortModified(c)
+		return false, false
+	} else if err != nil {
+		abortInternalError(c, err)
+		return false, false
+	}
 
 	// Schedule further executions
-	connectors.Scheduler.SetJob(&conn)
+	if previous == nil || previous.Refresh != conn.Refresh {
+		connectors.Scheduler.SetJob(conn)
+	} else {
+		connectors.Scheduler.UpdateJob(conn)
+	}
+	return changed, true
+}
+
+// checkConnectorPreconditions loads the connector, nil when it does not exist, and answers and returns false
+// when the preconditions of the request fail
+func checkConnectorPreconditions(c *gin.Context) (*connectors.Connector, bool) {
+	previous, err := loadConnector(c.Param("group"), c.Param("connector"))
+	if err != nil {
+		abortInternalError(c, err)
+		return nil, false
+	}
+	return previous, checkPreconditions(c, etagOf(previous))
+}
+
+// etagOf returns the ETag of the connector, empty when it does not exist
+func etagOf(conn *connectors.Connector) string {
+	if conn == nil {
+		return ""
+	}
+	return conn.ETag()
+}
+
+// ControllerCreateConnector creates or replaces a connector, and executes it
+func ControllerCreateConnector(c *gin.Context) {
+	previous, ok := checkConnectorPreconditions(c)
+	if !ok {
+		return
+	}
+	conn := readConnector(c)
+	if conn == nil {
+		return
+	}
+	if _, ok = saveConnector(c, conn, previous); !ok {
+		return
+	}
 
 	// Execute the connector
-	go connectors.Exec(&conn)
+	go connectors.Exec(conn)
 
+	c.Header("ETag", conn.ETag())
 	c.JSON(http.StatusOK, conn)
 }
 
-func ControllerDeleteConnector(c *gin.Context) {
-	group := c.Param("group")
-	connector := c.Param("connector")
+// ControllerPutConnector creates or replaces a connector, which is executed when its configuration changed
+func ControllerPutConnector(c *gin.Context) {
+	previous, ok := checkConnectorPreconditions(c)
+	if !ok {
+		return
+	}
+	conn := readConnector(c)
+	if conn == nil {
+		return
+	}
+	changed, ok := saveConnector(c, conn, previous)
+	if !ok {
+		return
+	}
+	if changed {
+		go connectors.Exec(conn)
+	}
 
-	conn, err := connectors.GetConnector(group, connector)
+	c.Header("ETag", conn.ETag())
+	if previous == nil {
+		c.JSON(http.StatusCreated, conn)
+	} else {
+		c.JSON(http.StatusOK, conn)
+	}
+}
+
+// ControllerPatchConnector updates a connector with a JSON merge patch, it is executed when its configuration changed
+func ControllerPatchConnector(c *gin.Context) {
+	previous, ok := checkConnectorPreconditions(c)
+	if !ok {
+		return
+	}
+	if previous == nil {
+		abortNotFound(c, "Connector %s:%s not found", c.Param("group"), c.Param("connector"))
+		return
+	}
+
+	patch, err := ioutil.ReadAll(c.Request.Body)
+	if err != nil {
+		abortWithError(c, http.StatusBadRequest, "Invalid patch", err)
+		return
+	}
+	patched, err := jsonmerge.Apply([]byte(previous.GetJSON()), patch)
+	if err != nil {
+		abortWithError(c, http.StatusBadRequest, "Invalid patch", err)
+		return
+	}
+	conn := &connectors.Connector{}
+	err = json.Unmarshal(patched, conn)
 	if err != nil {
-		c.String(http.StatusNotFound, err.Error())
+		abortWithError(c, http.StatusBadRequest, "Invalid patch", err)
+		return
+	}
+	errs := connectors.ValidationErrors{}
+	if conn.Group != previous.Group {
+		errs = append(errs, connectors.FieldError{Field: "group", Message: "cannot be changed"})
+	}
+	if conn.Name != previous.Name {
+		errs = append(errs, connectors.FieldError{Field: "name", Message: "cannot be changed"})
+	}
+	if len(errs) > 0 {
+		invalidConnector(c, errs)
+		return
 	}
 
-	// Remove further scheduled executions
-	connectors.Scheduler.RemoveJob(conn)
+	changed, ok := saveConnector(c, conn, previous)
+	if !ok {
+		return
+	}
+	if changed {
+		go connectors.Exec(conn)
+	}
+	c.Header("ETag", conn.ETag())
+	c.JSON(http.StatusOK, conn)
+}
+
+func ControllerDeleteConnector(c *gin.Context) {
+	conn := getConnector(c)
+	if conn == nil {
+		return
+	}
+	if !checkPreconditions(c, conn.ETag()) {
+		return
+	}
 
 	// Remove connector
-	connectors.RemoveConnector(conn)
+	var err error
+	if hasPreconditions(c) {
+		err = connectors.RemoveConnectorIf(conn, conn.ETag())
+	} else {
+		err = connectors.RemoveConnector(conn)
+	}
+	if err == connectors.ErrModified {
+		abortModified(c)
+		return
+	} else if err != nil {
+		abortInternalError(c, err)
+		return
+	}
+
+	// Remove further scheduled executions, once the connector is gone
+	connectors.Scheduler.RemoveJob(conn)
 
 	c.JSON(http.StatusOK, conn)
 }
+
+// getAuthor returns the user set under the "user" key by an authentication middleware, empty without authentication
+func getAuthor(c *gin.Context) string {
+	if user, ok := c.Get("user"); ok {
+		return fmt.Sprint(user)
+	}
+	return ""
+}
+
+// getConfigVersion loads the version of the configuration of the connector given by the parameter or the query
+// named name, and answers and returns nil when it is invalid or does not exist
+func getConfigVersion(c *gin.Context, conn *connectors.Connector, name string, value string) *connectors.ConfigVersion {
+	number, err := strconv.Atoi(value)
+	if err != nil || number < 1 {
+		abortWithError(c, http.StatusBadRequest, "Invalid version", fmt.Errorf("%s must be a positive integer", name))
+		return nil
+	}
+	version, err := connectors.GetConfigVersion(conn, number)
+	if err == connectors.ErrNotFound {
+		abortNotFound(c, "Version %d of %s:%s not found", number, conn.Group, conn.Name)
+		return nil
+	} else if err != nil {
+		abortInternalError(c, err)
+		return nil
+	}
+	return version
+}
+
+func ControllerGetConnectorVersions(c *gin.Context) {
+	conn := getConnector(c)
+	if conn == nil {
+		return
+	}
+	versions, err := connectors.GetConfigVersions(conn)
+	if err != nil {
+		abortInternalError(c, err)
+	} else {
+		c.JSON(http.StatusOK, versions)
+	}
+}
+
+func ControllerGetConnectorVersion(c *gin.Context) {
+	conn := getConnector(c)
+	if conn == nil {
+		return
+	}
+	version := getConfigVersion(c, conn, "version", c.Param("version"))
+	if version != nil {
+		c.JSON(http.StatusOK, version)
+	}
+}
+
+// ControllerDiffConnectorVersion compares a version with the version given by "to", the current configuration by default
+func ControllerDiffConnectorVersion(c *gin.Context) {
+	conn := getConnector(c)
+	if conn == nil {
+		return
+	}
+	from := getConfigVersion(c, conn, "version", c.Param("version"))
+	if from == nil {
+		return
+	}
+
+	to := conn
+	if sTo := c.Query("to"); sTo != "" {
+		toVersion := getConfigVersion(c, conn, "to", sTo)
+		if toVersion == nil {
+			return
+		}
+		to = toVersion.Connector
+	}
+
+	differences, err := connectors.DiffConfigs(from.Connector, to)
+	if err != nil {
+		abortInternalError(c, err)
+	} else {
+		c.JSON(http.StatusOK, differences)
+	}
+}
+
+// ControllerRollbackConnector restores an earlier version of the configuration, as a new version
+func ControllerRollbackConnector(c *gin.Context) {
+	conn := getConnector(c)
+	if conn == nil {
+		return
+	}
+	number, err := strconv.Atoi(c.Param("version"))
+	if err != nil || number < 1 {
+		abortWithError(c, http.StatusBadRequest, "Invalid version", fmt.Errorf("version must be a positive integer"))
+		return
+	}
+	if !checkPreconditions(c, conn.ETag()) {
+		return
+	}
+	var restored *connectors.Connector
+	if hasPreconditions(c) {
+		restored, err = connectors.RollbackIf(conn, number, getAuthor(c), conn.ETag())
+	} else {
+		restored, err = connectors.Rollback(conn, number, getAuthor(c))
+	}
+	if err == connectors.ErrNotFound {
+		abortNotFound(c, "Version %d of %s:%s not found", number, conn.Group, conn.Name)
+		return
+	} else if err == connectors.ErrModified {
+		abortModified(c)
+		return
+	} else if err != nil {
+		abortInternalError(c, err)
+		return
+	}
+
+	connectors.Scheduler.SetJob(restored)
+	c.Header("ETag", restored.ETag())
+	c.JSON(http.StatusOK, restored)
+}
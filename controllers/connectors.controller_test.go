@@ -0,0 +1,105 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/soprasteria/dockerapi"
+	"github.com/soprasteria/intools-engine/connectors"
+)
+
+func newConnectorsEngine() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	connectors.Repository = connectors.NewMemoryConnectorRepository()
+	engine := gin.New()
+	engine.PUT("/groups/:group/connectors/:connector", ControllerPutConnector)
+	engine.PATCH("/groups/:group/connectors/:connector", ControllerPatchConnector)
+	return engine
+}
+
+func request(engine *gin.Engine, method string, path string, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(method, path, bytes.NewBufferString(body))
+	engine.ServeHTTP(w, req)
+	return w
+}
+
+// invalidFields returns the fields of the errors answered for an invalid connector
+func invalidFields(t *testing.T, w *httptest.ResponseRecorder) []string {
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400, got %d : %s", w.Code, w.Body.String())
+	}
+	var apiError APIError
+	if err := json.Unmarshal(w.Body.Bytes(), &apiError); err != nil {
+		t.Fatalf("Invalid error %s: %s", w.Body.String(), err)
+	}
+	if apiError.Code != CodeInvalidConnector {
+		t.Errorf("Expected code %s, got %s", CodeInvalidConnector, apiError.Code)
+	}
+	fields := []string{}
+	for _, fieldError := range apiError.Errors {
+		fields = append(fields, fieldError.Field)
+	}
+	return fields
+}
+
+func TestPutConnectorAnswersInvalidFields(t *testing.T) {
+	tests := []struct {
+		path   string
+		body   string
+		fields []string
+	}{
+		{"/groups/g/connectors/c", `{}`, []string{"config"}},
+		{"/groups/g/connectors/c", `{"config":{"Image":" "}}`, []string{"config.Image"}},
+		{"/groups/g/connectors/c", `{"config":{"Image":"busybox"},"timeout":7200,"refresh":99999999}`, []string{"timeout", "refresh"}},
+		{"/groups/g/connectors/c", `{"config":{"Image":"busybox"},"schema":{"type":"date"},"series":{"":"$.a"},"retention":{"maxAge":-2}}`, []string{"schema", "series", "retention"}},
+		{"/groups/-g/connectors/.c", `{"config":{"Image":"busybox"}}`, []string{"group", "name"}},
+	}
+	for _, test := range tests {
+		engine := newConnectorsEngine()
+		fields := invalidFields(t, request(engine, "PUT", test.path, test.body))
+		if !reflect.DeepEqual(fields, test.fields) {
+			t.Errorf("PUT %s: expected fields %v, got %v", test.body, test.fields, fields)
+		}
+		if exists, _ := connectors.Repository.ConnectorExists("g", "c"); exists {
+			t.Errorf("PUT %s: expected an invalid connector not to be saved", test.body)
+		}
+	}
+}
+
+func TestPatchConnectorAnswersInvalidFields(t *testing.T) {
+	tests := []struct {
+		patch  string
+		fields []string
+	}{
+		{`{"config":null}`, []string{"config"}},
+		{`{"config":{"Image":""}}`, []string{"config.Image"}},
+		{`{"name":"other","group":"other"}`, []string{"group", "name"}},
+		{`{"timeout":0,"refresh":0,"retention":{"maxExecutions":-5}}`, []string{"retention"}},
+	}
+	for _, test := range tests {
+		engine := newConnectorsEngine()
+		stored := &connectors.Connector{Group: "g", Name: "c", ContainerConfig: &dockerapi.ContainerOptions{Image: "busybox"}, Timeout: 10}
+		connectors.Repository.SaveConnector(stored)
+
+		fields := invalidFields(t, request(engine, "PATCH", "/groups/g/connectors/c", test.patch))
+		if !reflect.DeepEqual(fields, test.fields) {
+			t.Errorf("PATCH %s: expected fields %v, got %v", test.patch, test.fields, fields)
+		}
+		if conn, _ := connectors.Repository.GetConnector("g", "c"); conn.ETag() != stored.ETag() {
+			t.Errorf("PATCH %s: expected an invalid patch not to be saved", test.patch)
+		}
+	}
+}
+
+func TestPatchMissingConnector(t *testing.T) {
+	w := request(newConnectorsEngine(), "PATCH", "/groups/g/connectors/c", `{"timeout":10}`)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", w.Code)
+	}
+}
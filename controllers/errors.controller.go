@@ -0,0 +1,85 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/gin-gonic/gin"
+	"github.com/soprasteria/intools-engine/connectors"
+)
+
+// Codes of the errors answered by the REST API
+const (
+	CodeBadRequest         = "bad_request"
+	CodeInvalidConnector   = "invalid_connector"
+	CodeInvalidGroup       = "invalid_group"
+	CodeNotFound           = "not_found"
+	CodePreconditionFailed = "precondition_failed"
+	CodeInternalError      = "internal_error"
+)
+
+// APIError is the body of every error answered by the REST API
+type APIError struct {
+	Code      string                      `json:"code"`
+	Message   string                      `json:"message"`
+	Details   string                      `json:"details,omitempty"`
+	RequestID string                      `json:"requestId,omitempty"`
+	Errors    connectors.ValidationErrors `json:"errors,omitempty"`
+}
+
+func codeOf(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return CodeBadRequest
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusPreconditionFailed:
+		return CodePreconditionFailed
+	default:
+		return CodeInternalError
+	}
+}
+
+// abort answers the error and stops the handlers of the request
+func abort(c *gin.Context, status int, apiError APIError) {
+	apiError.RequestID = getRequestID(c)
+	entry := log.WithFields(log.Fields{
+		"requestId": apiError.RequestID,
+		"method":    c.Request.Method,
+		"uri":       c.Request.RequestURI,
+		"status":    status,
+		"details":   apiError.Details,
+	})
+	if status >= http.StatusInternalServerError {
+		entry.Error(apiError.Message)
+	} else {
+		entry.Warn(apiError.Message)
+	}
+	c.JSON(status, apiError)
+	c.Abort()
+}
+
+// abortWithError answers an error with the status, the message and the details of err, which may be nil
+func abortWithError(c *gin.Context, status int, message string, err error) {
+	apiError := APIError{Code: codeOf(status), Message: message}
+	if err != nil {
+		apiError.Details = err.Error()
+	}
+	abort(c, status, apiError)
+}
+
+// abortNotFound answers 404 Not Found
+func abortNotFound(c *gin.Context, format string, args ...interface{}) {
+	abortWithError(c, http.StatusNotFound, fmt.Sprintf(format, args...), nil)
+}
+
+// abortInternalError answers 500 Internal Server Error
+func abortInternalError(c *gin.Context, err error) {
+	abortWithError(c, http.StatusInternalServerError, "Internal error", err)
+}
+
+// ControllerNoRoute answers the requests of unknown routes
+func ControllerNoRoute(c *gin.Context) {
+	abortNotFound(c, "No route for %s %s", c.Request.Method, c.Request.URL.Path)
+}
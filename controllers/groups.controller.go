@@ -1,65 +1,232 @@
 package controllers
 
 import (
+	"encoding/json"
 	"net/http"
 
-	log "github.com/Sirupsen/logrus"
 	"github.com/gin-gonic/gin"
 	"github.com/soprasteria/intools-engine/connectors"
 	"github.com/soprasteria/intools-engine/groups"
 )
 
 func ControllerGetGroups(c *gin.Context) {
-	groups := groups.GetGroups(false)
-	c.JSON(http.StatusOK, groups)
+	page, ok := readPage(c, groups.SortName)
+	if !ok {
+		return
+	}
+	list, err := groups.ListGroups(c.Query("prefix"), page)
+	if err != nil {
+		abortInternalError(c, err)
+		return
+	}
+	setTotalCount(c, list.Total)
+	if isDeprecated(c) {
+		c.JSON(http.StatusOK, list.Groups)
+	} else {
+		c.JSON(http.StatusOK, list)
+	}
 }
 
 func ControllerGetGroup(c *gin.Context) {
 	group := c.Param("group")
-	g := groups.GetGroup(group, false)
-	if g == nil {
-		c.String(http.StatusNotFound, "")
-	} else {
-		c.JSON(http.StatusOK, g)
+	etag, err := groups.GetGroupETag(group)
+	if err != nil {
+		abortInternalError(c, err)
+	} else if etag == "" {
+		abortNotFound(c, "Group %s not found", group)
+	} else if !notModified(c, etag) {
+		c.JSON(http.StatusOK, groups.GetGroup(group, false))
+	}
+}
+
+// checkGroupPreconditions answers and returns false when the group does not exist, or when the preconditions fail
+func checkGroupPreconditions(c *gin.Context, group string) bool {
+	etag, err := groups.GetGroupETag(group)
+	if err != nil {
+		abortInternalError(c, err)
+		return false
+	}
+	if etag == "" {
+		abortNotFound(c, "Group %s not found", group)
+		return false
 	}
+	return checkPreconditions(c, etag)
 }
 
 func ControllerPostGroup(c *gin.Context) {
 	group := c.Param("group")
+	if err := connectors.CheckName(group); err != nil {
+		abortWithError(c, http.StatusBadRequest, "Invalid group name", err)
+		return
+	}
+	preconditionMutex.Lock()
+	defer preconditionMutex.Unlock()
+	etag, err := groups.GetGroupETag(group)
+	if err != nil {
+		abortInternalError(c, err)
+		return
+	}
+	if !checkPreconditions(c, etag) {
+		return
+	}
 	created, err := groups.CreateGroup(group)
 	if err != nil {
-		c.String(http.StatusInternalServerError, err.Error())
+		abortInternalError(c, err)
+		return
+	}
+	if etag, err = groups.GetGroupETag(group); err == nil {
+		c.Header("ETag", etag)
+	}
+	if created {
+		c.JSON(http.StatusCreated, groups.GetGroup(group, false))
 	} else {
-		if created {
-			c.String(http.StatusCreated, "%s created", group)
-		} else {
-			c.String(http.StatusOK, "%s already exists", group)
-		}
+		c.JSON(http.StatusOK, groups.GetGroup(group, false))
+	}
+}
+
+// groupSettings is the body of PUT /groups/:group
+type groupSettings struct {
+	groups.Metadata
+	Defaults *connectors.Defaults `json:"defaults"`
+}
+
+// ControllerPutGroup replaces the metadata and the defaults of a group, creating it when it does not exist
+func ControllerPutGroup(c *gin.Context) {
+	group := c.Param("group")
+	if err := connectors.CheckName(group); err != nil {
+		abortWithError(c, http.StatusBadRequest, "Invalid group name", err)
+		return
+	}
+	var settings groupSettings
+	if err := json.NewDecoder(c.Request.Body).Decode(&settings); err != nil {
+		abortWithError(c, http.StatusBadRequest, "Invalid group", err)
+		return
+	}
+	errs := append(groups.CheckMetadata(&settings.Metadata), connectors.CheckDefaults(settings.Defaults)...)
+	if len(errs) > 0 {
+		abort(c, http.StatusBadRequest, APIError{
+			Code:    CodeInvalidGroup,
+			Message: "Invalid group",
+			Details: errs.Error(),
+			Errors:  errs,
+		})
+		return
+	}
+
+	preconditionMutex.Lock()
+	defer preconditionMutex.Unlock()
+	etag, err := groups.GetGroupETag(group)
+	if err != nil {
+		abortInternalError(c, err)
+		return
+	}
+	if !checkPreconditions(c, etag) {
+		return
+	}
+	created, err := groups.UpdateGroup(group, &settings.Metadata, settings.Defaults)
+	if err != nil {
+		abortInternalError(c, err)
+		return
+	}
+	if etag, err = groups.GetGroupETag(group); err == nil {
+		c.Header("ETag", etag)
+	}
+	if created {
+		c.JSON(http.StatusCreated, groups.GetGroup(group, false))
+	} else {
+		c.JSON(http.StatusOK, groups.GetGroup(group, false))
 	}
 }
 
 func ControllerDeleteGroup(c *gin.Context) {
 	group := c.Param("group")
-	err := groups.DeleteGroup(group)
+	preconditionMutex.Lock()
+	defer preconditionMutex.Unlock()
+	if !checkGroupPreconditions(c, group) {
+		return
+	}
+
+	dryRun := c.Query("dryRun") == "true"
+	report, err := groups.DeleteGroup(group, dryRun)
 	if err != nil {
-		c.String(http.StatusInternalServerError, err.Error())
+		abortInternalError(c, err)
+	} else {
+		c.JSON(http.StatusOK, report)
 	}
+}
 
-	conns, err := connectors.RedisGetConnectors(group)
+func ControllerGetGroupRetention(c *gin.Context) {
+	group := c.Param("group")
+	if groups.GetGroup(group, false) == nil {
+		abortNotFound(c, "Group %s not found", group)
+		return
+	}
+	retention, err := connectors.GetGroupRetention(group)
 	if err != nil {
-		c.String(http.StatusInternalServerError, err.Error())
+		abortInternalError(c, err)
+	} else if retention == nil {
+		c.JSON(http.StatusOK, connectors.Retention{})
+	} else {
+		c.JSON(http.StatusOK, retention)
 	}
+}
 
-	for _, cId := range conns {
-		conn, err := connectors.GetConnector(group, cId)
-		if err != nil {
-			log.WithError(err).WithField("connectorId", cId).Error("Cant get connector from Redis")
-		}
-		// Remove further scheduled executions
-		connectors.Scheduler.RemoveJob(conn)
-		connectors.RemoveConnector(conn)
+func ControllerPutGroupRetention(c *gin.Context) {
+	group := c.Param("group")
+	preconditionMutex.Lock()
+	defer preconditionMutex.Unlock()
+	if !checkGroupPreconditions(c, group) {
+		return
+	}
+	var retention connectors.Retention
+	if err := json.NewDecoder(c.Request.Body).Decode(&retention); err != nil {
+		abortWithError(c, http.StatusBadRequest, "Invalid retention", err)
+		return
+	}
+	if err := connectors.CheckRetention(&retention); err != nil {
+		abortWithError(c, http.StatusBadRequest, "Invalid retention", err)
+		return
+	}
+	err := connectors.SetGroupRetention(group, &retention)
+	if err != nil {
+		abortInternalError(c, err)
+		return
 	}
+	if etag, err := groups.GetGroupETag(group); err == nil {
+		c.Header("ETag", etag)
+	}
+	c.JSON(http.StatusOK, retention)
+}
 
-	c.String(http.StatusOK, "%s deleted", group)
+func ControllerDeleteGroupRetention(c *gin.Context) {
+	group := c.Param("group")
+	preconditionMutex.Lock()
+	defer preconditionMutex.Unlock()
+	if !checkGroupPreconditions(c, group) {
+		return
+	}
+	err := connectors.SetGroupRetention(group, nil)
+	if err != nil {
+		abortInternalError(c, err)
+	} else {
+		c.Status(http.StatusNoContent)
+	}
+}
 
+func ControllerGetCompaction(c *gin.Context) {
+	report := groups.GetLastCompaction()
+	if report == nil {
+		abortNotFound(c, "No compaction yet")
+	} else {
+		c.JSON(http.StatusOK, report)
+	}
+}
+
+func ControllerPostCompaction(c *gin.Context) {
+	report, err := groups.Compact()
+	if err != nil {
+		abortInternalError(c, err)
+	} else {
+		c.JSON(http.StatusOK, report)
+	}
 }
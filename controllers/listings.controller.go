@@ -0,0 +1,47 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/soprasteria/intools-engine/common/listing"
+)
+
+// readPage reads the limit, the offset and the sort of a listing, sorted by the first field by default.
+// It answers 400 Bad Request and returns false when they are invalid.
+func readPage(c *gin.Context, fields ...string) (listing.Page, bool) {
+	page := listing.Page{Sort: fields[0]}
+	if sLimit := c.Query("limit"); sLimit != "" {
+		limit, err := strconv.Atoi(sLimit)
+		if err != nil || limit <= 0 || limit > listing.MaxLimit {
+			abortWithError(c, http.StatusBadRequest, "Invalid limit", fmt.Errorf("limit must be between 1 and %d", listing.MaxLimit))
+			return page, false
+		}
+		page.Limit = limit
+	}
+	if sOffset := c.Query("offset"); sOffset != "" {
+		offset, err := strconv.Atoi(sOffset)
+		if err != nil || offset < 0 {
+			abortWithError(c, http.StatusBadRequest, "Invalid offset", fmt.Errorf("offset must be a positive integer"))
+			return page, false
+		}
+		page.Offset = offset
+	}
+	if sort := c.Query("sort"); sort != "" {
+		field, desc, err := listing.ParseSort(sort, fields...)
+		if err != nil {
+			abortWithError(c, http.StatusBadRequest, "Invalid sort", err)
+			return page, false
+		}
+		page.Sort = field
+		page.Desc = desc
+	}
+	return page, true
+}
+
+// setTotalCount sets the number of items of a listing, over all its pages
+func setTotalCount(c *gin.Context, total int) {
+	c.Header("X-Total-Count", strconv.Itoa(total))
+}
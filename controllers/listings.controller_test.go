@@ -0,0 +1,51 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/soprasteria/intools-engine/common/listing"
+)
+
+func TestReadPage(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		query string
+		page  listing.Page
+		valid bool
+	}{
+		{"", listing.Page{Sort: "name"}, true},
+		{"limit=20&offset=40&sort=-lastRun", listing.Page{Sort: "lastRun", Desc: true, Offset: 40, Limit: 20}, true},
+		{"limit=" + strconv.Itoa(listing.MaxLimit), listing.Page{Sort: "name", Limit: listing.MaxLimit}, true},
+		{"offset=100000", listing.Page{Sort: "name", Offset: 100000}, true},
+		{"limit=0", listing.Page{}, false},
+		{"limit=" + strconv.Itoa(listing.MaxLimit+1), listing.Page{}, false},
+		{"limit=-1", listing.Page{}, false},
+		{"limit=ten", listing.Page{}, false},
+		{"offset=-1", listing.Page{}, false},
+		{"sort=size", listing.Page{}, false},
+	}
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("GET", "/groups?"+test.query, nil)
+
+		page, ok := readPage(c, "name", "lastRun")
+		if ok != test.valid {
+			t.Errorf("%q: expected valid %t, got %t", test.query, test.valid, ok)
+			continue
+		}
+		if !ok {
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("%q: expected status 400, got %d", test.query, w.Code)
+			}
+			continue
+		}
+		if page != test.page {
+			t.Errorf("%q: expected %+v, got %+v", test.query, test.page, page)
+		}
+	}
+}
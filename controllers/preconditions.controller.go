@@ -0,0 +1,68 @@
+package controllers
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// preconditionMutex serializes the checks of preconditions on groups with the writes they guard, in this engine.
+// The writes of connectors are checked by the repository instead.
+var preconditionMutex sync.Mutex
+
+// matchETag tells whether a If-Match or If-None-Match header matches the ETag, empty when the resource does not exist.
+// The weak comparison of If-None-Match ignores the W/ prefix, the strong comparison of If-Match never matches weak tags.
+func matchETag(header string, etag string, weak bool) bool {
+	if etag == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if weak {
+			candidate = strings.TrimPrefix(candidate, "W/")
+		}
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// hasPreconditions tells whether the request has If-Match or If-None-Match headers, its write must then be
+// conditioned on the ETag they were checked against
+func hasPreconditions(c *gin.Context) bool {
+	return c.Request.Header.Get("If-Match") != "" || c.Request.Header.Get("If-None-Match") != ""
+}
+
+// abortModified answers 412 Precondition Failed when the resource is modified between the check of the
+// preconditions and the write
+func abortModified(c *gin.Context) {
+	abortWithError(c, http.StatusPreconditionFailed, "The resource has been modified", nil)
+}
+
+// checkPreconditions answers 412 Precondition Failed and returns false when the If-Match or If-None-Match headers
+// of an update do not match the current ETag of the resource, empty when it does not exist
+func checkPreconditions(c *gin.Context, etag string) bool {
+	if ifMatch := c.Request.Header.Get("If-Match"); ifMatch != "" && !matchETag(ifMatch, etag, false) {
+		abortWithError(c, http.StatusPreconditionFailed, "The resource has been modified or does not exist", nil)
+		return false
+	}
+	if ifNoneMatch := c.Request.Header.Get("If-None-Match"); ifNoneMatch != "" && matchETag(ifNoneMatch, etag, true) {
+		abortWithError(c, http.StatusPreconditionFailed, "The resource already exists", nil)
+		return false
+	}
+	return true
+}
+
+// notModified answers 304 Not Modified and returns true when the If-None-Match header of a read matches the ETag,
+// which is set in the response otherwise
+func notModified(c *gin.Context, etag string) bool {
+	c.Header("ETag", etag)
+	if ifNoneMatch := c.Request.Header.Get("If-None-Match"); ifNoneMatch != "" && matchETag(ifNoneMatch, etag, true) {
+		c.Status(http.StatusNotModified)
+		return true
+	}
+	return false
+}
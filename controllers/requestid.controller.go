@@ -0,0 +1,45 @@
+package controllers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	requestIDHeader = "X-Request-Id"
+	requestIDKey    = "requestId"
+)
+
+// validRequestID limits the ids given by clients, which end up in the logs
+var validRequestID = regexp.MustCompile(`^[a-zA-Z0-9_.-]{1,64}$`)
+
+// RequestID keeps the X-Request-Id header of the request, or generates one, and returns it in the response
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.Request.Header.Get(requestIDHeader)
+		if !validRequestID.MatchString(id) {
+			id = newRequestID()
+		}
+		c.Set(requestIDKey, id)
+		c.Header(requestIDHeader, id)
+		c.Next()
+	}
+}
+
+func newRequestID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
+
+func getRequestID(c *gin.Context) string {
+	if id, ok := c.Get(requestIDKey); ok {
+		return id.(string)
+	}
+	return ""
+}
@@ -7,7 +7,6 @@ import (
 	"os"
 
 	log "github.com/Sirupsen/logrus"
-	"github.com/soprasteria/intools-engine/common/utils"
 
 	"github.com/gin-gonic/gin"
 )
@@ -19,7 +18,7 @@ func GetLogs(c *gin.Context, path string) {
 	if err == nil {
 		if content == "" {
 			err = errors.New("Logs not found in " + path)
-			c.JSON(http.StatusNotFound, utils.HandleError("Logs not found", err, c))
+			abortWithError(c, http.StatusNotFound, "Logs not found", err)
 		} else {
 			switch format {
 			case "text", "raw":
@@ -34,7 +33,7 @@ func GetLogs(c *gin.Context, path string) {
 			}
 		}
 	} else {
-		c.JSON(http.StatusInternalServerError, utils.HandleError("Unable to get logs", err, c))
+		abortWithError(c, http.StatusInternalServerError, "Unable to get logs", err)
 	}
 }
 
@@ -1,15 +1,51 @@
 package executors
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
+	"fmt"
 	"time"
 
 	log "github.com/Sirupsen/logrus"
+	"github.com/soprasteria/intools-engine/common/jsonschema"
 )
 
+// Outcomes of the validation of a connector output
+const (
+	ResultValid           = "valid"
+	ResultNotJSON         = "not-json"
+	ResultSchemaViolation = "schema-violation"
+)
+
+// Status is the state of an execution
+type Status string
+
+// Statuses of an execution, the last five are final
+const (
+	StatusQueued        Status = "queued"
+	StatusRunning       Status = "running"
+	StatusSucceeded     Status = "succeeded"
+	StatusFailed        Status = "failed"
+	StatusTimedOut      Status = "timed-out"
+	StatusInvalidOutput Status = "invalid-output"
+	StatusEngineError   Status = "engine-error"
+)
+
+// Artifact is a file produced by an execution, kept in the artifact store
+type Artifact struct {
+	Name        string
+	Size        int64
+	ContentType string
+}
+
 type Executor struct {
+	Id          string
+	Status      Status
+	Error       string
 	ContainerId string
 	Host        string
+	CreatedAt   time.Time
 	Running     bool
 	Terminated  bool
 	ExitCode    int
@@ -19,6 +55,26 @@ type Executor struct {
 	StartedAt   time.Time
 	FinishedAt  time.Time
 	Valid       bool
+	Validation  string
+	Violations  []jsonschema.ValidationError
+	Artifacts   []Artifact
+	// ConfigVersion is the version of the configuration of the connector which ran
+	ConfigVersion int
+}
+
+// NewExecutor returns a queued execution with a new id
+func NewExecutor() *Executor {
+	return &Executor{Id: NewExecutionId(), Status: StatusQueued, CreatedAt: time.Now().UTC()}
+}
+
+// NewExecutionId generates a unique id for an execution, ordered by time
+func NewExecutionId() string {
+	random := make([]byte, 4)
+	_, err := rand.Read(random)
+	if err != nil {
+		log.WithError(err).Warn("Cannot generate random part of execution id")
+	}
+	return time.Now().UTC().Format("20060102T150405") + "-" + hex.EncodeToString(random)
 }
 
 func (e *Executor) GetJSON() string {
@@ -38,3 +94,75 @@ func (e *Executor) GetResult() string {
 	}
 	return string(b[:])
 }
+
+// Start marks the execution as running
+func (e *Executor) Start() {
+	e.Status = StatusRunning
+	e.Running = true
+}
+
+// Fail marks the execution as stopped by an error of the engine
+func (e *Executor) Fail(err error) {
+	e.Status = StatusEngineError
+	e.Error = err.Error()
+	e.Running = false
+	e.Terminated = true
+	e.Valid = false
+}
+
+// Finish sets the final status of the execution from the way the connector ended and from its output.
+// Only a succeeded execution is valid.
+func (e *Executor) Finish(exitCode int, timedOut bool) {
+	e.ExitCode = exitCode
+	e.Running = false
+	e.Terminated = true
+	switch {
+	case timedOut:
+		e.Status = StatusTimedOut
+		e.Error = "connector has been stopped after its timeout"
+	case exitCode != 0:
+		e.Status = StatusFailed
+		e.Error = fmt.Sprintf("connector exited with code %d", exitCode)
+	case e.Validation != ResultValid:
+		e.Status = StatusInvalidOutput
+		e.Error = "output of connector is " + e.Validation
+	default:
+		e.Status = StatusSucceeded
+		e.Error = ""
+	}
+	e.Valid = e.Status == StatusSucceeded
+}
+
+// ValidateStdout parses the stdout of the container and checks it against the schema of the connector, if any.
+// Only a valid output is kept as JsonStdout.
+func (e *Executor) ValidateStdout(stdout []byte, schema map[string]interface{}) {
+	e.JsonStdout = nil
+	e.Violations = nil
+
+	var document interface{}
+	err := json.Unmarshal(stdout, &document)
+	if err != nil {
+		log.WithError(err).Warn("Output of connector is not JSON")
+		e.Validation = ResultNotJSON
+		return
+	}
+	result, ok := document.(map[string]interface{})
+	if !ok {
+		log.Warn("Output of connector is not a JSON object")
+		e.Validation = ResultNotJSON
+		return
+	}
+
+	if schema != nil {
+		violations := jsonschema.Validate(schema, document)
+		if len(violations) > 0 {
+			log.WithField("violations", violations).Warn("Output of connector does not match its schema")
+			e.Validation = ResultSchemaViolation
+			e.Violations = violations
+			return
+		}
+	}
+
+	e.Validation = ResultValid
+	e.JsonStdout = &result
+}
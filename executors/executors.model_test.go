@@ -0,0 +1,131 @@
+package executors
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/soprasteria/intools-engine/common/jsonschema"
+)
+
+func TestValidateStdout(t *testing.T) {
+	schema := map[string]interface{}{
+		"type":     "object",
+		"required": []interface{}{"value"},
+	}
+	tests := []struct {
+		name       string
+		stdout     string
+		schema     map[string]interface{}
+		validation string
+		result     *map[string]interface{}
+		violations []jsonschema.ValidationError
+	}{
+		{"object without schema", `{"value":1}`, nil, ResultValid, &map[string]interface{}{"value": 1.0}, nil},
+		{"object matching schema", `{"value":1}`, schema, ResultValid, &map[string]interface{}{"value": 1.0}, nil},
+		{"schema violation", `{"other":1}`, schema, ResultSchemaViolation, nil, []jsonschema.ValidationError{{Path: "$", Message: `missing required property "value"`}}},
+		{"not JSON", `done`, nil, ResultNotJSON, nil, nil},
+		{"array", `[1,2]`, nil, ResultNotJSON, nil, nil},
+		{"string", `"done"`, schema, ResultNotJSON, nil, nil},
+		{"empty", ``, nil, ResultNotJSON, nil, nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			e := NewExecutor()
+			// A previous output is replaced
+			e.JsonStdout = &map[string]interface{}{"previous": true}
+			e.ValidateStdout([]byte(test.stdout), test.schema)
+			if e.Validation != test.validation {
+				t.Errorf("Expected validation %s, got %s", test.validation, e.Validation)
+			}
+			if !reflect.DeepEqual(e.JsonStdout, test.result) {
+				t.Errorf("Expected result %v, got %v", test.result, e.JsonStdout)
+			}
+			if !reflect.DeepEqual(e.Violations, test.violations) {
+				t.Errorf("Expected violations %v, got %v", test.violations, e.Violations)
+			}
+		})
+	}
+}
+
+func TestFinishAfterValidation(t *testing.T) {
+	tests := []struct {
+		stdout string
+		status Status
+		valid  bool
+	}{
+		{`{"value":1}`, StatusSucceeded, true},
+		{`not json`, StatusInvalidOutput, false},
+	}
+	for _, test := range tests {
+		e := NewExecutor()
+		e.Start()
+		e.ValidateStdout([]byte(test.stdout), nil)
+		e.Finish(0, false)
+		if e.Status != test.status || e.Valid != test.valid {
+			t.Errorf("Output %s: expected %s and valid %t, got %s and valid %t", test.stdout, test.status, test.valid, e.Status, e.Valid)
+		}
+	}
+}
+
+func TestStatusTransitions(t *testing.T) {
+	e := NewExecutor()
+	if e.Status != StatusQueued || e.Running || e.Terminated {
+		t.Errorf("Expected a new execution to be queued, got %s", e.Status)
+	}
+	e.Start()
+	if e.Status != StatusRunning || !e.Running || e.Terminated {
+		t.Errorf("Expected a started execution to be running, got %s", e.Status)
+	}
+}
+
+func TestFinish(t *testing.T) {
+	tests := []struct {
+		name       string
+		validation string
+		exitCode   int
+		timedOut   bool
+		status     Status
+		err        string
+	}{
+		{"succeeded", ResultValid, 0, false, StatusSucceeded, ""},
+		{"exit code", ResultValid, 2, false, StatusFailed, "connector exited with code 2"},
+		{"timeout wins over exit code", ResultValid, 137, true, StatusTimedOut, "connector has been stopped after its timeout"},
+		{"exit code wins over output", ResultNotJSON, 1, false, StatusFailed, "connector exited with code 1"},
+		{"not json", ResultNotJSON, 0, false, StatusInvalidOutput, "output of connector is not-json"},
+		{"schema violation", ResultSchemaViolation, 0, false, StatusInvalidOutput, "output of connector is schema-violation"},
+	}
+	for _, test := range tests {
+		e := NewExecutor()
+		e.Start()
+		e.Error = "previous error"
+		e.Validation = test.validation
+		e.Finish(test.exitCode, test.timedOut)
+		if e.Status != test.status || e.Error != test.err {
+			t.Errorf("%s: expected %s %q, got %s %q", test.name, test.status, test.err, e.Status, e.Error)
+		}
+		if e.Valid != (test.status == StatusSucceeded) {
+			t.Errorf("%s: expected valid %t, got %t", test.name, test.status == StatusSucceeded, e.Valid)
+		}
+		if e.Running || !e.Terminated || e.ExitCode != test.exitCode {
+			t.Errorf("%s: expected a terminated execution with exit code %d", test.name, test.exitCode)
+		}
+	}
+}
+
+func TestFail(t *testing.T) {
+	for _, started := range []bool{false, true} {
+		e := NewExecutor()
+		if started {
+			e.Start()
+		}
+		e.Valid = true
+		e.Fail(errors.New("cannot create container"))
+		if e.Status != StatusEngineError || e.Error != "cannot create container" {
+			t.Errorf("Expected an engine error, got %s %q", e.Status, e.Error)
+		}
+		if e.Valid || e.Running || !e.Terminated {
+			t.Error("Expected a failed execution to be terminated and not valid")
+		}
+	}
+}
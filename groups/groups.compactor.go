@@ -0,0 +1,80 @@
+package groups
+
+import (
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/soprasteria/intools-engine/connectors"
+)
+
+var (
+	compactionMutex sync.Mutex
+	lastCompaction  *connectors.CompactionReport
+)
+
+// Compact applies the retention of all the connectors, and removes what is left of deleted connectors
+func Compact() (*connectors.CompactionReport, error) {
+	compactionMutex.Lock()
+	defer compactionMutex.Unlock()
+
+	report := &connectors.CompactionReport{StartedAt: time.Now().UTC()}
+	groups, err := Repository.GetGroups()
+	if err != nil {
+		return nil, err
+	}
+	for _, group := range groups {
+		names, err := connectors.GetConnectorNames(group)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range names {
+			conn, err := connectors.GetConnector(group, name)
+			if err != nil {
+				continue
+			}
+			err = connectors.Compact(conn, report)
+			if err != nil {
+				log.WithError(err).Warnf("Cannot compact connector %s", conn.Id())
+			}
+		}
+	}
+
+	report.Orphans, err = connectors.Repository.RemoveOrphans()
+	if err != nil {
+		log.WithError(err).Warn("Cannot remove orphans of deleted connectors")
+	}
+	report.FinishedAt = time.Now().UTC()
+
+	log.WithFields(log.Fields{
+		"connectors": report.Connectors,
+		"executions": report.Executions,
+		"artifacts":  report.Artifacts,
+		"results":    report.Results,
+		"orphans":    report.Orphans,
+		"bytes":      report.Bytes,
+	}).Info("Compaction done")
+	lastCompaction = report
+	return report, nil
+}
+
+// GetLastCompaction returns the report of the last compaction, nil when none has run yet
+func GetLastCompaction() *connectors.CompactionReport {
+	compactionMutex.Lock()
+	defer compactionMutex.Unlock()
+	return lastCompaction
+}
+
+// StartCompactor periodically compacts the store
+func StartCompactor(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	go func() {
+		for _ = range ticker.C {
+			_, err := Compact()
+			if err != nil {
+				log.WithError(err).Error("Compaction failed")
+			}
+		}
+	}()
+	log.Infof("Compactor will apply retention every %s", interval)
+}
@@ -0,0 +1,100 @@
+package groups
+
+import (
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/soprasteria/intools-engine/common/store"
+)
+
+// groupFile is the content of groups/<group>/group.json
+type groupFile struct {
+	Created  time.Time `json:"created"`
+	Metadata *Metadata `json:"metadata,omitempty"`
+}
+
+// FileGroupRepository is a MemoryGroupRepository which writes each group to its own file, groups/<group>/group.json
+// under the directory of the store. The connectors of the group are kept next to it.
+type FileGroupRepository struct {
+	*MemoryGroupRepository
+	path    string
+	created map[string]time.Time
+	// mutex keeps the files in the order of the changes
+	mutex sync.Mutex
+}
+
+// NewFileGroupRepository loads the groups of the store under path, when they exist
+func NewFileGroupRepository(path string) (*FileGroupRepository, error) {
+	r := &FileGroupRepository{MemoryGroupRepository: NewMemoryGroupRepository(), path: path, created: map[string]time.Time{}}
+	names, err := store.ListDir(filepath.Join(path, "groups"))
+	if err != nil {
+		log.WithError(err).WithField("path", path).Error("Unable to load groups store")
+		return nil, err
+	}
+	for _, group := range names {
+		var file *groupFile
+		err = store.LoadJSON(r.file(group), &file)
+		if err != nil {
+			log.WithError(err).WithField("file", r.file(group)).Error("Unable to load group")
+			return nil, err
+		}
+		// Only the data of the connectors is left
+		if file == nil {
+			continue
+		}
+		r.created[group] = file.Created
+		r.groups = append(r.groups, group)
+		if file.Metadata != nil {
+			r.metadata[group] = file.Metadata
+		}
+	}
+	// Most recently created first
+	sort.SliceStable(r.groups, func(i, j int) bool {
+		return r.created[r.groups[i]].After(r.created[r.groups[j]])
+	})
+	return r, nil
+}
+
+func (r *FileGroupRepository) file(group string) string {
+	return filepath.Join(r.path, "groups", store.Escape(group), "group.json")
+}
+
+func (r *FileGroupRepository) CreateGroup(group string) (bool, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	created, err := r.MemoryGroupRepository.CreateGroup(group)
+	if err != nil || !created {
+		return created, err
+	}
+	r.created[group] = time.Now()
+	return true, store.SaveJSON(r.file(group), &groupFile{Created: r.created[group]})
+}
+
+func (r *FileGroupRepository) DeleteGroup(group string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	err := r.MemoryGroupRepository.DeleteGroup(group)
+	if err != nil {
+		return err
+	}
+	delete(r.created, group)
+	return store.Remove(filepath.Dir(r.file(group)))
+}
+
+func (r *FileGroupRepository) SaveMetadata(group string, metadata *Metadata) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	err := r.MemoryGroupRepository.SaveMetadata(group, metadata)
+	if err != nil {
+		return err
+	}
+	created, ok := r.created[group]
+	if !ok {
+		created = time.Now()
+		r.created[group] = created
+	}
+	return store.SaveJSON(r.file(group), &groupFile{Created: created, Metadata: metadata})
+}
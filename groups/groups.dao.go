@@ -1,42 +1,68 @@
 package groups
 
 import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+
 	log "github.com/Sirupsen/logrus"
 	"github.com/soprasteria/intools-engine/connectors"
 )
 
 func GetGroup(name string, withConnectors bool) *Group {
-	allGroups := GetGroups(withConnectors)
-	for _, g := range allGroups {
-		if g.Name == name {
-			return &g
-		}
+	exists, err := Repository.GroupExists(name)
+	if err != nil {
+		log.Errorf("Error while getting group %s %s", name, err.Error())
+		return nil
+	}
+	if !exists {
+		return nil
+	}
+	group := &Group{Name: name}
+	loadSettings(group)
+	if withConnectors {
+		group.Connectors = connectors.GetConnectors(name)
+	}
+	return group
+}
+
+// loadSettings loads the metadata and the defaults of the group, which are left empty when they cannot be loaded
+func loadSettings(group *Group) {
+	metadata, err := Repository.GetMetadata(group.Name)
+	if err != nil {
+		log.Errorf("Error while getting metadata of group %s %s", group.Name, err.Error())
+	} else {
+		group.Metadata = *metadata
+	}
+	group.Defaults, err = connectors.GetGroupDefaults(group.Name)
+	if err != nil {
+		log.Errorf("Error while getting defaults of group %s %s", group.Name, err.Error())
 	}
-	return nil
 }
 
 func GetGroupsLength() int64 {
-	length, err := RedisGetLength()
+	length, err := Repository.GetLength()
 	if err != nil {
-		log.Errorf("Error while getting groups length from Redis %s", err.Error())
+		log.Errorf("Error while getting groups length %s", err.Error())
 		return 0
 	}
 	return length
 }
 
 func GetGroups(withConnectors bool) []Group {
-	log.Debug("Fetching all groups from Redis...")
-	groups, err := RedisGetGroups()
+	log.Debug("Fetching all groups...")
+	groups, err := Repository.GetGroups()
 	if err != nil {
-		log.Errorf("Error while getting groups from Redis %s", err.Error())
+		log.Errorf("Error while getting groups %s", err.Error())
 		return nil
 	}
-	log.WithField("nbGroupFromRedis", len(groups)).Debug("Fetched all groups from Redis")
+	log.WithField("nbGroup", len(groups)).Debug("Fetched all groups")
 	allGroups := make([]Group, len(groups))
 	for i, g := range groups {
 		group := &Group{
 			Name: g,
 		}
+		loadSettings(group)
 		if withConnectors {
 			connectors := connectors.GetConnectors(g)
 			group.Connectors = connectors
@@ -46,10 +72,73 @@ func GetGroups(withConnectors bool) []Group {
 	return allGroups
 }
 
+// GetGroupETag identifies the current state of a group for HTTP preconditions : its metadata, its defaults,
+// its retention and its connectors. It is derived from the version of the group, bumped by their writes, and is
+// empty when the group does not exist.
+func GetGroupETag(group string) (string, error) {
+	exists, err := Repository.GroupExists(group)
+	if err != nil || !exists {
+		return "", err
+	}
+	version, err := connectors.Repository.GetGroupVersion(group)
+	if err != nil {
+		return "", err
+	}
+	hash := sha1.Sum([]byte(fmt.Sprintf("%s\n%d", group, version)))
+	return "\"" + hex.EncodeToString(hash[:8]) + "\"", nil
+}
+
 func CreateGroup(group string) (bool, error) {
-	return RedisCreateGroup(group)
+	return Repository.CreateGroup(group)
 }
 
-func DeleteGroup(group string) error {
-	return RedisDeleteGroup(group)
+// DeleteGroup removes a group with all its connectors. The connectors are unscheduled first, so that no execution
+// recreates their data. With dryRun, nothing is removed and the report tells what would be.
+func DeleteGroup(group string, dryRun bool) (*DeletionReport, error) {
+	names, err := connectors.GetConnectorNames(group)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &DeletionReport{Group: group, DryRun: dryRun, Connectors: []string{}}
+	for _, name := range names {
+		conn, err := connectors.GetConnector(group, name)
+		if err != nil {
+			// The configuration is gone, what is left is removed with the group
+			log.WithError(err).WithField("connector", name).Warn("Cannot load connector of deleted group")
+			continue
+		}
+		ids, err := connectors.Repository.GetExecutionIds(conn)
+		if err != nil {
+			return nil, err
+		}
+		report.Connectors = append(report.Connectors, name)
+		report.Executions += len(ids)
+		if dryRun {
+			continue
+		}
+		connectors.Scheduler.RemoveJob(conn)
+		err = connectors.RemoveConnector(conn)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if dryRun {
+		return report, nil
+	}
+	err = connectors.SetGroupRetention(group, nil)
+	if err != nil {
+		return nil, err
+	}
+	err = connectors.SetGroupDefaults(group, nil)
+	if err != nil {
+		return nil, err
+	}
+	err = Repository.DeleteGroup(group)
+	if err != nil {
+		return nil, err
+	}
+	log.WithFields(log.Fields{"group": group, "connectors": len(report.Connectors), "executions": report.Executions}).Info("Group deleted")
+	return report, nil
 }
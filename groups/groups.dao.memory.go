@@ -0,0 +1,98 @@
+package groups
+
+import (
+	"sync"
+)
+
+// MemoryGroupRepository keeps the groups in the memory of the engine, they are lost when the engine stops
+type MemoryGroupRepository struct {
+	mutex sync.RWMutex
+	// Groups, most recently created first
+	groups []string
+	// Metadata of each group
+	metadata map[string]*Metadata
+}
+
+func NewMemoryGroupRepository() *MemoryGroupRepository {
+	return &MemoryGroupRepository{groups: []string{}, metadata: map[string]*Metadata{}}
+}
+
+func (r *MemoryGroupRepository) GetGroups() ([]string, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return append([]string{}, r.groups...), nil
+}
+
+func (r *MemoryGroupRepository) GetLength() (int64, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return int64(len(r.groups)), nil
+}
+
+func (r *MemoryGroupRepository) GroupExists(group string) (bool, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return r.exists(group), nil
+}
+
+func (r *MemoryGroupRepository) exists(group string) bool {
+	for _, g := range r.groups {
+		if g == group {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *MemoryGroupRepository) CreateGroup(group string) (bool, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.exists(group) {
+		return false, nil
+	}
+	r.groups = append([]string{group}, r.groups...)
+	return true, nil
+}
+
+func (r *MemoryGroupRepository) DeleteGroup(group string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	kept := make([]string, 0, len(r.groups))
+	for _, g := range r.groups {
+		if g != group {
+			kept = append(kept, g)
+		}
+	}
+	r.groups = kept
+	delete(r.metadata, group)
+	return nil
+}
+
+func (r *MemoryGroupRepository) GetMetadata(group string) (*Metadata, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	metadata, ok := r.metadata[group]
+	if !ok {
+		return &Metadata{}, nil
+	}
+	return copyMetadata(metadata), nil
+}
+
+func (r *MemoryGroupRepository) SaveMetadata(group string, metadata *Metadata) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.metadata[group] = copyMetadata(metadata)
+	return nil
+}
+
+// copyMetadata returns a copy of the metadata sharing nothing with it
+func copyMetadata(m *Metadata) *Metadata {
+	copied := &Metadata{Description: m.Description, Owners: append([]string(nil), m.Owners...)}
+	if m.Labels != nil {
+		copied.Labels = make(map[string]string, len(m.Labels))
+		for key, value := range m.Labels {
+			copied.Labels[key] = value
+		}
+	}
+	return copied
+}
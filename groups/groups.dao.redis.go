@@ -1,18 +1,26 @@
 package groups
 
 import (
-	"fmt"
+	"encoding/json"
+	"sort"
 
 	log "github.com/Sirupsen/logrus"
+	"github.com/soprasteria/intools-engine/common/keys"
+	"github.com/soprasteria/intools-engine/executors"
 	"github.com/soprasteria/intools-engine/intools"
+	"gopkg.in/redis.v3"
 )
 
 func GetRedisGroupsKey() string {
-	return "intools:groups"
+	return keys.Groups()
 }
 
 func GetRedisGroupKey(group string) string {
-	return "intools:groups:" + group
+	return keys.Group(group)
+}
+
+func GetRedisMetadataKey(group string) string {
+	return keys.Metadata(group)
 }
 
 func RedisGetLength() (int64, error) {
@@ -20,12 +28,7 @@ func RedisGetLength() (int64, error) {
 	if err != nil {
 		return 0, err
 	}
-	defer r.Close()
-	len, err := r.LLen(GetRedisGroupsKey()).Result()
-	if err != nil {
-		return 0, err
-	}
-	return len, nil
+	return r.SCard(GetRedisGroupsKey()).Result()
 }
 
 func RedisGetGroups() ([]string, error) {
@@ -33,16 +36,20 @@ func RedisGetGroups() ([]string, error) {
 	if err != nil {
 		return nil, err
 	}
-	defer r.Close()
-	groupLength := r.LLen(GetRedisGroupsKey())
-	if groupLength == nil {
-		return []string{}, nil
-	}
-	len, err := groupLength.Result()
+	groups, err := r.SMembers(GetRedisGroupsKey()).Result()
 	if err != nil {
 		return nil, err
 	}
-	return r.LRange(GetRedisGroupsKey(), 0, len).Result()
+	sort.Strings(groups)
+	return groups, nil
+}
+
+func RedisGroupExists(group string) (bool, error) {
+	r, err := intools.Engine.GetRedisClient()
+	if err != nil {
+		return false, err
+	}
+	return r.SIsMember(GetRedisGroupsKey(), group).Result()
 }
 
 func RedisCreateGroup(group string) (bool, error) {
@@ -50,45 +57,159 @@ func RedisCreateGroup(group string) (bool, error) {
 	if err != nil {
 		return false, err
 	}
-	defer r.Close()
-	listGroup, err := RedisGetGroups()
+	added, err := r.SAdd(GetRedisGroupsKey(), group).Result()
 	if err != nil {
 		return false, err
 	}
-	for _, g := range listGroup {
-		if group == g {
-			return false, nil
+	return added > 0, nil
+}
+
+// RedisDeleteGroup removes the keys of the connectors still listed in the group, the keys of the group, then the
+// group from the list of groups. Only the keys known to belong to the group are removed, without scanning.
+func RedisDeleteGroup(group string) error {
+	r, err := intools.Engine.GetRedisClient()
+	if err != nil {
+		return err
+	}
+
+	names, err := r.SMembers(keys.Connectors(group)).Result()
+	if err != nil {
+		return err
+	}
+	owned := []string{}
+	for _, name := range names {
+		ids, err := r.ZRange(keys.Executions(group, name), 0, -1).Result()
+		if err != nil {
+			return err
 		}
+		for _, id := range ids {
+			owned = append(owned, keys.Execution(group, name, id))
+		}
+		artifacts, err := redisArtifactKeys(r, group, name, ids)
+		if err != nil {
+			return err
+		}
+		owned = append(owned, artifacts...)
+		// The series of the connector are named in its configuration
+		conf := struct {
+			Series map[string]string `json:"series"`
+		}{}
+		if b, err := r.Get(keys.ConnectorConf(group, name)).Bytes(); err == nil && json.Unmarshal(b, &conf) == nil {
+			for series := range conf.Series {
+				owned = append(owned, keys.Series(group, name, series))
+			}
+		}
+		owned = append(owned,
+			keys.ConnectorConf(group, name),
+			keys.Executor(group, name),
+			keys.Result(group, name),
+			keys.Executions(group, name),
+			keys.Versions(group, name),
+			keys.VersionCounter(group, name))
 	}
-	cmd1 := r.LPush(GetRedisGroupsKey(), group)
-	if cmd1.Err() != nil {
-		return false, cmd1.Err()
-	} else {
-		return true, nil
+	owned = append(owned, keys.Retention(group), keys.Metadata(group), keys.Defaults(group), keys.Connectors(group))
+	err = intools.RedisDel(r, owned...)
+	if err != nil {
+		return err
 	}
+	log.Debugf("Keys of group %s removed, %d connectors were left", group, len(names))
+
+	return r.SRem(GetRedisGroupsKey(), group).Err()
 }
 
-func RedisDeleteGroup(group string) error {
+// redisArtifactKeys returns the keys of the artifacts listed by the executions of a connector
+func redisArtifactKeys(r intools.RedisWrapper, group string, name string, ids []string) ([]string, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	executions := make([]string, len(ids))
+	for i, id := range ids {
+		executions[i] = keys.Execution(group, name, id)
+	}
+	values, err := intools.RedisMGet(r, executions...)
+	if err != nil {
+		return nil, err
+	}
+	artifacts := []string{}
+	for i, value := range values {
+		sExecution, ok := value.(string)
+		if !ok {
+			continue
+		}
+		execution := &executors.Executor{}
+		if json.Unmarshal([]byte(sExecution), execution) != nil {
+			continue
+		}
+		for _, artifact := range execution.Artifacts {
+			artifacts = append(artifacts, keys.Artifact(group, name, ids[i], artifact.Name))
+		}
+	}
+	return artifacts, nil
+}
+
+func RedisGetMetadata(group string) (string, error) {
+	r, err := intools.Engine.GetRedisClient()
+	if err != nil {
+		return "", err
+	}
+	return r.Get(GetRedisMetadataKey(group)).Result()
+}
+
+func RedisSaveMetadata(group string, metadata *Metadata) error {
 	r, err := intools.Engine.GetRedisClient()
 	if err != nil {
 		return err
 	}
-	defer r.Close()
-	keyGroup := fmt.Sprintf("intools:groups:%s:*", group)
-	evalKeys := make([]string, 0)
-	evalArgs := []string{keyGroup}
-	//TODO This remove too much data
-	cmd := r.Eval("return redis.call('del', unpack(redis.call('keys', ARGV[1])))", evalKeys, evalArgs)
+	b, err := json.Marshal(metadata)
+	if err != nil {
+		return err
+	}
+	return r.Set(GetRedisMetadataKey(group), string(b), 0).Err()
+}
+
+// RedisGroupRepository is the GroupRepository backed by the Redis server of the engine
+type RedisGroupRepository struct{}
+
+func NewRedisGroupRepository() *RedisGroupRepository {
+	return &RedisGroupRepository{}
+}
 
-	log.Debugf("%s --> %d", keyGroup, cmd.Val())
-	if cmd.Err() != nil {
-		return cmd.Err()
+func (r *RedisGroupRepository) GetGroups() ([]string, error) {
+	return RedisGetGroups()
+}
+
+func (r *RedisGroupRepository) GetLength() (int64, error) {
+	return RedisGetLength()
+}
+
+func (r *RedisGroupRepository) GroupExists(group string) (bool, error) {
+	return RedisGroupExists(group)
+}
+
+func (r *RedisGroupRepository) CreateGroup(group string) (bool, error) {
+	return RedisCreateGroup(group)
+}
+
+func (r *RedisGroupRepository) DeleteGroup(group string) error {
+	return RedisDeleteGroup(group)
+}
+
+func (r *RedisGroupRepository) GetMetadata(group string) (*Metadata, error) {
+	sMetadata, err := RedisGetMetadata(group)
+	if err == redis.Nil {
+		return &Metadata{}, nil
+	}
+	if err != nil {
+		return nil, err
 	}
-	//TODO delete branch
-	key := GetRedisGroupsKey()
-	cmd1 := r.LRem(key, 0, group)
-	if cmd1.Err() != nil {
-		return cmd1.Err()
+	metadata := &Metadata{}
+	err = json.Unmarshal([]byte(sMetadata), metadata)
+	if err != nil {
+		return nil, err
 	}
-	return nil
+	return metadata, nil
+}
+
+func (r *RedisGroupRepository) SaveMetadata(group string, metadata *Metadata) error {
+	return RedisSaveMetadata(group, metadata)
 }
@@ -0,0 +1,149 @@
+package groups
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/soprasteria/intools-engine/artifacts"
+	"github.com/soprasteria/intools-engine/connectors"
+	"github.com/soprasteria/intools-engine/executors"
+)
+
+// newDeletedGroup stores a group with two connectors, their executions and an artifact of the first one
+func newDeletedGroup(t *testing.T) {
+	Repository = NewMemoryGroupRepository()
+	connectors.Repository = connectors.NewMemoryConnectorRepository()
+	store, err := artifacts.NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileStore failed: %s", err)
+	}
+	connectors.ArtifactStore = store
+
+	Repository.CreateGroup("g")
+	Repository.SaveMetadata("g", &Metadata{Description: "deleted"})
+	connectors.SetGroupRetention("g", &connectors.Retention{MaxExecutions: 5})
+	now := time.Now().UTC()
+	for i, name := range []string{"a", "b"} {
+		c := &connectors.Connector{Group: "g", Name: name}
+		connectors.Repository.SaveConnector(c)
+		for j, id := range []string{name + "1", name + "2"} {
+			exec := &executors.Executor{Id: id, CreatedAt: now.Add(time.Duration(i*2+j) * time.Second)}
+			if id == "a1" {
+				store.Save("g", "a", id, "report.txt", []byte("report"))
+				exec.Artifacts = []executors.Artifact{{Name: "report.txt", Size: 6}}
+			}
+			connectors.Repository.SaveExecutor(c, exec)
+		}
+	}
+}
+
+func TestDeleteGroupDryRun(t *testing.T) {
+	newDeletedGroup(t)
+
+	report, err := DeleteGroup("g", true)
+	if err != nil {
+		t.Fatalf("DeleteGroup failed: %s", err)
+	}
+	expected := &DeletionReport{Group: "g", DryRun: true, Connectors: []string{"b", "a"}, Executions: 4}
+	if !reflect.DeepEqual(report, expected) {
+		t.Errorf("Expected report %+v, got %+v", expected, report)
+	}
+	if exists, _ := Repository.GroupExists("g"); !exists {
+		t.Error("Expected a dry run to keep the group")
+	}
+	if names, _ := connectors.GetConnectorNames("g"); len(names) != 2 {
+		t.Errorf("Expected a dry run to keep the connectors, got %v", names)
+	}
+	if ids, _ := connectors.Repository.GetExecutionIds(&connectors.Connector{Group: "g", Name: "a"}); len(ids) != 2 {
+		t.Errorf("Expected a dry run to keep the executions, got %v", ids)
+	}
+	if _, err := connectors.ArtifactStore.Get("g", "a", "a1", "report.txt"); err != nil {
+		t.Errorf("Expected a dry run to keep the artifacts, got %v", err)
+	}
+	if retention, _ := connectors.GetGroupRetention("g"); retention == nil {
+		t.Error("Expected a dry run to keep the retention")
+	}
+}
+
+func TestDeleteGroup(t *testing.T) {
+	newDeletedGroup(t)
+
+	report, err := DeleteGroup("g", false)
+	if err != nil {
+		t.Fatalf("DeleteGroup failed: %s", err)
+	}
+	expected := &DeletionReport{Group: "g", Connectors: []string{"b", "a"}, Executions: 4}
+	if !reflect.DeepEqual(report, expected) {
+		t.Errorf("Expected report %+v, got %+v", expected, report)
+	}
+	if exists, _ := Repository.GroupExists("g"); exists {
+		t.Error("Expected the group to be removed")
+	}
+	if metadata, _ := Repository.GetMetadata("g"); metadata.Description != "" {
+		t.Errorf("Expected the metadata to be removed, got %+v", metadata)
+	}
+	if names, _ := connectors.GetConnectorNames("g"); len(names) != 0 {
+		t.Errorf("Expected the connectors to be removed, got %v", names)
+	}
+	if ids, _ := connectors.Repository.GetExecutionIds(&connectors.Connector{Group: "g", Name: "a"}); len(ids) != 0 {
+		t.Errorf("Expected the executions to be removed, got %v", ids)
+	}
+	if _, err := connectors.ArtifactStore.Get("g", "a", "a1", "report.txt"); err != artifacts.ErrNotFound {
+		t.Errorf("Expected the artifacts to be removed, got %v", err)
+	}
+	if retention, _ := connectors.GetGroupRetention("g"); retention != nil {
+		t.Errorf("Expected the retention to be removed, got %+v", retention)
+	}
+}
+
+func TestGroupETagChangesWithWrites(t *testing.T) {
+	Repository = NewMemoryGroupRepository()
+	connectors.Repository = connectors.NewMemoryConnectorRepository()
+	if etag, _ := GetGroupETag("g"); etag != "" {
+		t.Errorf("Expected no ETag for a missing group, got %s", etag)
+	}
+	Repository.CreateGroup("g")
+	c := &connectors.Connector{Group: "g", Name: "a"}
+
+	etags := map[string]string{}
+	writes := []struct {
+		name  string
+		write func() error
+	}{
+		{"metadata", func() error { return SaveMetadata("g", &Metadata{Description: "changed"}) }},
+		{"defaults", func() error { return connectors.SetGroupDefaults("g", &connectors.Defaults{Timeout: 10}) }},
+		{"retention", func() error { return connectors.SetGroupRetention("g", &connectors.Retention{MaxExecutions: 5}) }},
+		{"connector", func() error { return connectors.Repository.SaveConnector(c) }},
+		{"connector removed", func() error { return connectors.Repository.RemoveConnector(c) }},
+	}
+	previous, _ := GetGroupETag("g")
+	for _, w := range writes {
+		if err := w.write(); err != nil {
+			t.Fatalf("%s: write failed: %s", w.name, err)
+		}
+		etag, err := GetGroupETag("g")
+		if err != nil {
+			t.Fatalf("%s: GetGroupETag failed: %s", w.name, err)
+		}
+		if etag == previous {
+			t.Errorf("%s: expected the ETag to change", w.name)
+		}
+		if other, ok := etags[etag]; ok {
+			t.Errorf("%s: ETag already given after %s", w.name, other)
+		}
+		etags[etag] = w.name
+		previous = etag
+	}
+
+	connectors.Repository.SaveExecutor(c, &executors.Executor{Id: "1", CreatedAt: time.Now()})
+	if etag, _ := GetGroupETag("g"); etag != previous {
+		t.Error("Expected an execution to keep the ETag")
+	}
+
+	DeleteGroup("g", false)
+	Repository.CreateGroup("g")
+	if etag, _ := GetGroupETag("g"); etags[etag] != "" {
+		t.Errorf("Expected a group created again not to reuse the ETag given after %s", etags[etag])
+	}
+}
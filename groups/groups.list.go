@@ -0,0 +1,48 @@
+package groups
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/soprasteria/intools-engine/common/listing"
+)
+
+// SortName is the only field the groups can be sorted by
+const SortName = "name"
+
+// GroupList is a page of the groups
+type GroupList struct {
+	Groups []Group `json:"groups"`
+	// Total is the number of groups matching the prefix
+	Total  int `json:"total"`
+	Offset int `json:"offset"`
+	Limit  int `json:"limit,omitempty"`
+}
+
+// ListGroups returns the page of the groups whose name starts with the prefix, sorted by name
+func ListGroups(prefix string, page listing.Page) (*GroupList, error) {
+	names, err := Repository.GetGroups()
+	if err != nil {
+		return nil, err
+	}
+	matching := []string{}
+	for _, name := range names {
+		if strings.HasPrefix(name, prefix) {
+			matching = append(matching, name)
+		}
+	}
+	if page.Desc {
+		sort.Sort(sort.Reverse(sort.StringSlice(matching)))
+	} else {
+		sort.Strings(matching)
+	}
+
+	start, end := page.Bounds(len(matching))
+	list := &GroupList{Groups: make([]Group, 0, end-start), Total: len(matching), Offset: page.Offset, Limit: page.Limit}
+	for _, name := range matching[start:end] {
+		group := Group{Name: name}
+		loadSettings(&group)
+		list.Groups = append(list.Groups, group)
+	}
+	return list, nil
+}
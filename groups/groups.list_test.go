@@ -0,0 +1,47 @@
+package groups
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/soprasteria/intools-engine/common/listing"
+	"github.com/soprasteria/intools-engine/connectors"
+)
+
+func TestListGroups(t *testing.T) {
+	Repository = NewMemoryGroupRepository()
+	connectors.Repository = connectors.NewMemoryConnectorRepository()
+	for _, name := range []string{"b", "a", "d", "c", "other"} {
+		Repository.CreateGroup(name)
+	}
+
+	tests := []struct {
+		name   string
+		prefix string
+		page   listing.Page
+		groups []string
+	}{
+		{"all by name", "", listing.Page{Sort: SortName}, []string{"a", "b", "c", "d", "other"}},
+		{"descending", "", listing.Page{Sort: SortName, Desc: true}, []string{"other", "d", "c", "b", "a"}},
+		{"page", "", listing.Page{Sort: SortName, Offset: 1, Limit: 2}, []string{"b", "c"}},
+		{"descending page", "", listing.Page{Sort: SortName, Desc: true, Offset: 1, Limit: 2}, []string{"d", "c"}},
+		{"offset past the end", "", listing.Page{Sort: SortName, Offset: 10, Limit: 2}, []string{}},
+		{"prefix", "o", listing.Page{Sort: SortName}, []string{"other"}},
+	}
+	for _, test := range tests {
+		list, err := ListGroups(test.prefix, test.page)
+		if err != nil {
+			t.Fatalf("%s: ListGroups failed: %s", test.name, err)
+		}
+		names := []string{}
+		for _, group := range list.Groups {
+			names = append(names, group.Name)
+		}
+		if !reflect.DeepEqual(names, test.groups) {
+			t.Errorf("%s: expected %v, got %v", test.name, test.groups, names)
+		}
+		if test.prefix == "" && list.Total != 5 {
+			t.Errorf("%s: expected a total of 5, got %d", test.name, list.Total)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package groups
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/soprasteria/intools-engine/connectors"
+)
+
+// CheckMetadata validates the metadata of a group, and returns its invalid fields, nil when it is valid
+func CheckMetadata(m *Metadata) connectors.ValidationErrors {
+	errors := connectors.ValidationErrors{}
+	for i, owner := range m.Owners {
+		if strings.TrimSpace(owner) == "" {
+			errors = append(errors, connectors.FieldError{Field: fmt.Sprintf("owners[%d]", i), Message: "cannot be empty"})
+		}
+	}
+	for key := range m.Labels {
+		if strings.TrimSpace(key) == "" {
+			errors = append(errors, connectors.FieldError{Field: "labels", Message: "keys cannot be empty"})
+		}
+	}
+	if len(errors) == 0 {
+		return nil
+	}
+	return errors
+}
+
+// UpdateGroup replaces the metadata and the defaults of a group, creating it when it does not exist.
+// It returns whether the group was created.
+func UpdateGroup(group string, metadata *Metadata, defaults *connectors.Defaults) (bool, error) {
+	created, err := Repository.CreateGroup(group)
+	if err != nil {
+		return false, err
+	}
+	err = SaveMetadata(group, metadata)
+	if err != nil {
+		return created, err
+	}
+	return created, connectors.SetGroupDefaults(group, defaults)
+}
+
+// SaveMetadata replaces the metadata of a group, and changes its version
+func SaveMetadata(group string, metadata *Metadata) error {
+	err := Repository.SaveMetadata(group, metadata)
+	if err != nil {
+		return err
+	}
+	return connectors.Repository.BumpGroupVersion(group)
+}
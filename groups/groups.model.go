@@ -4,7 +4,26 @@ import (
 	"github.com/soprasteria/intools-engine/connectors"
 )
 
+// Metadata describes a group for the people using it
+type Metadata struct {
+	Description string            `json:"description,omitempty"`
+	Owners      []string          `json:"owners,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+}
+
 type Group struct {
-	Name       string                 `json:"name"`
+	Name string `json:"name"`
+	Metadata
+	// Defaults are inherited by the connectors of the group which do not set them
+	Defaults   *connectors.Defaults   `json:"defaults,omitempty"`
 	Connectors []connectors.Connector `json:"connectors,omitempty"`
 }
+
+// DeletionReport describes what is removed with a group
+type DeletionReport struct {
+	Group  string `json:"group"`
+	DryRun bool   `json:"dryRun"`
+	// Connectors removed, with their schedule, executions, results, series and artifacts
+	Connectors []string `json:"connectors"`
+	Executions int      `json:"executions"`
+}
@@ -0,0 +1,55 @@
+package groups
+
+import (
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/soprasteria/intools-engine/connectors"
+)
+
+// Reload schedules the stored connectors which this engine does not run yet : the ones stored before it started,
+// and the ones created by the command line or by another engine. The jobs of the connectors removed from the
+// store stop at their next execution. It returns the number of connectors scheduled.
+func Reload() (int, error) {
+	groups, err := Repository.GetGroups()
+	if err != nil {
+		return 0, err
+	}
+	scheduled := 0
+	for _, group := range groups {
+		names, err := connectors.GetConnectorNames(group)
+		if err != nil {
+			return scheduled, err
+		}
+		for _, name := range names {
+			conn, err := connectors.GetConnector(group, name)
+			if err != nil || connectors.Scheduler.IsScheduled(conn) {
+				continue
+			}
+			connectors.Scheduler.SetJob(conn)
+			scheduled++
+		}
+	}
+	if scheduled > 0 {
+		log.Infof("Reloader scheduled %d connectors found in the store", scheduled)
+	}
+	return scheduled, nil
+}
+
+// StartReloader schedules the stored connectors now, then periodically
+func StartReloader(interval time.Duration) {
+	_, err := Reload()
+	if err != nil {
+		log.WithError(err).Error("Cannot reload connectors")
+	}
+	ticker := time.NewTicker(interval)
+	go func() {
+		for _ = range ticker.C {
+			_, err := Reload()
+			if err != nil {
+				log.WithError(err).Error("Cannot reload connectors")
+			}
+		}
+	}()
+	log.Infof("Reloader will schedule the new connectors of the store every %s", interval)
+}
@@ -0,0 +1,19 @@
+package groups
+
+// Repository persists the groups, Redis by default
+var Repository GroupRepository = NewRedisGroupRepository()
+
+// GroupRepository is the persistence of the list of groups
+type GroupRepository interface {
+	GetGroups() ([]string, error)
+	GetLength() (int64, error)
+	GroupExists(group string) (bool, error)
+	// CreateGroup adds a group, it returns false when the group already exists
+	CreateGroup(group string) (bool, error)
+	// DeleteGroup removes the group, and whatever the store still keeps under it
+	DeleteGroup(group string) error
+	// GetMetadata returns the metadata of a group, empty when not set
+	GetMetadata(group string) (*Metadata, error)
+	// SaveMetadata replaces the metadata of a group
+	SaveMetadata(group string, metadata *Metadata) error
+}
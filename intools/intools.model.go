@@ -1,20 +1,24 @@
 package intools
 
 import (
+	"errors"
 	"time"
 
-	log "github.com/Sirupsen/logrus"
 	"github.com/soprasteria/dockerapi"
-	"github.com/soprasteria/intools-engine/common/utils"
+	"github.com/soprasteria/intools-engine/backends"
 	. "gopkg.in/redis.v3"
 )
 
 type IntoolsEngine interface {
+	GetEngineId() string
 	GetDockerClient() *dockerapi.Client
 	GetDockerHost() string
+	GetBackend() backends.Backend
 	GetRedisClient() (RedisWrapper, error)
 }
 
+// RedisWrapper is the set of commands shared by the Redis clients of every mode and by their transactions.
+// Transactions and multi-key commands go through RedisTransaction, RedisMGet and RedisDel to work in every mode.
 type RedisWrapper interface {
 	Close() error
 	Process(cmd Cmder)
@@ -31,7 +35,6 @@ type RedisWrapper interface {
 	Keys(pattern string) *StringSliceCmd
 	Migrate(host, port, key string, db int64, timeout time.Duration) *StatusCmd
 	Move(key string, db int64) *BoolCmd
-	Multi() *Multi
 	ObjectRefCount(keys ...string) *IntCmd
 	ObjectEncoding(keys ...string) *StringCmd
 	ObjectIdleTime(keys ...string) *DurationCmd
@@ -182,11 +185,17 @@ type RedisWrapper interface {
 }
 
 type IntoolsEngineImpl struct {
+	EngineId     string
 	DockerClient *dockerapi.Client
 	DockerHost   string
+	Backend      backends.Backend
 	RedisClient  RedisWrapper
 }
 
+func (e *IntoolsEngineImpl) GetEngineId() string {
+	return e.EngineId
+}
+
 func (e *IntoolsEngineImpl) GetDockerClient() *dockerapi.Client {
 	return e.DockerClient
 }
@@ -195,15 +204,15 @@ func (e *IntoolsEngineImpl) GetDockerHost() string {
 	return e.DockerHost
 }
 
+func (e *IntoolsEngineImpl) GetBackend() backends.Backend {
+	return e.Backend
+}
+
+// GetRedisClient returns the pooled client of the engine, shared by all the callers which must not close it
 func (e *IntoolsEngineImpl) GetRedisClient() (RedisWrapper, error) {
-	log.Debug("Getting redis client")
-	client, err := utils.GetRedisClient()
-	if err != nil {
-		return nil, err
+	if e.RedisClient == nil {
+		return nil, errors.New("Redis is not configured")
 	}
-	log.WithField("client", client).Debug("Connected to redis client")
-	e.RedisClient = client
-
 	return e.RedisClient, nil
 }
 
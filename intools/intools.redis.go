@@ -0,0 +1,260 @@
+package intools
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"gopkg.in/redis.v3"
+)
+
+// Modes of connection to Redis
+const (
+	RedisModeSingle   = "single"
+	RedisModeSentinel = "sentinel"
+	RedisModeCluster  = "cluster"
+)
+
+// RedisConfig describes how to connect to Redis. Addrs is the server in single mode, the sentinels in
+// sentinel mode and some nodes of the cluster in cluster mode.
+type RedisConfig struct {
+	Mode       string
+	Addrs      []string
+	MasterName string
+	Password   string
+	DB         int64
+	PoolSize   int
+}
+
+// Password of the cluster, used to connect to each of its masters when scanning
+var clusterPassword string
+
+// NewRedisClient connects to Redis with a pooled client, meant to be shared by the whole engine
+func NewRedisClient(config RedisConfig) (RedisWrapper, error) {
+	if len(config.Addrs) == 0 {
+		return nil, errors.New("No Redis address")
+	}
+
+	var client RedisWrapper
+	switch config.Mode {
+	case RedisModeSingle, "":
+		client = redis.NewClient(&redis.Options{
+			Addr:         config.Addrs[0],
+			Password:     config.Password,
+			DB:           config.DB,
+			PoolSize:     config.PoolSize,
+			PoolTimeout:  2 * time.Minute,
+			IdleTimeout:  10 * time.Minute,
+			ReadTimeout:  2 * time.Minute,
+			WriteTimeout: 1 * time.Minute,
+			MaxRetries:   10,
+			DialTimeout:  1 * time.Minute,
+		})
+	case RedisModeSentinel:
+		if config.MasterName == "" {
+			return nil, errors.New("Redis master name is required in sentinel mode")
+		}
+		client = redis.NewFailoverClient(&redis.FailoverOptions{
+			MasterName:    config.MasterName,
+			SentinelAddrs: config.Addrs,
+			Password:      config.Password,
+			DB:            config.DB,
+			PoolSize:      config.PoolSize,
+			PoolTimeout:   2 * time.Minute,
+			IdleTimeout:   10 * time.Minute,
+			ReadTimeout:   2 * time.Minute,
+			WriteTimeout:  1 * time.Minute,
+			MaxRetries:    10,
+			DialTimeout:   1 * time.Minute,
+		})
+	case RedisModeCluster:
+		if config.DB != 0 {
+			log.Warn("Redis Cluster has a single database, the Redis database is ignored")
+		}
+		clusterPassword = config.Password
+		client = redis.NewClusterClient(&redis.ClusterOptions{
+			Addrs:        config.Addrs,
+			Password:     config.Password,
+			PoolSize:     config.PoolSize,
+			PoolTimeout:  2 * time.Minute,
+			IdleTimeout:  10 * time.Minute,
+			ReadTimeout:  2 * time.Minute,
+			WriteTimeout: 1 * time.Minute,
+			DialTimeout:  1 * time.Minute,
+		})
+	default:
+		return nil, errors.New("Unknown Redis mode " + config.Mode)
+	}
+
+	_, err := client.Ping().Result()
+	if err != nil {
+		log.WithError(err).WithField("addrs", strings.Join(config.Addrs, ",")).Error("Unable to connect to redis host")
+		client.Close()
+		return nil, err
+	}
+	return client, nil
+}
+
+// RedisTransaction runs the commands of f in a MULTI/EXEC transaction. Redis Cluster does not support
+// transactions, the commands are then sent one by one.
+func RedisTransaction(r RedisWrapper, f func(tx RedisWrapper) error) error {
+	client, ok := r.(*redis.Client)
+	if !ok {
+		return f(r)
+	}
+	multi := client.Multi()
+	defer multi.Close()
+	_, err := multi.Exec(func() error {
+		return f(multi)
+	})
+	return err
+}
+
+// ErrKeyModified is returned by RedisCheckAndSet when a watched key is modified by another client before the
+// commands are run
+var ErrKeyModified = errors.New("Redis key modified concurrently")
+
+// RedisCheckAndSet watches keys, reads them with check and runs the commands of f in a MULTI/EXEC transaction when
+// check succeeds. The transaction fails with ErrKeyModified when one of the keys changes in between. Redis Cluster
+// does not support transactions, the keys are then checked and the commands sent one by one.
+func RedisCheckAndSet(r RedisWrapper, keys []string, check func(r RedisWrapper) error, f func(tx RedisWrapper) error) error {
+	client, ok := r.(*redis.Client)
+	if !ok {
+		err := check(r)
+		if err != nil {
+			return err
+		}
+		return f(r)
+	}
+	multi := client.Multi()
+	defer multi.Close()
+	err := multi.Watch(keys...).Err()
+	if err != nil {
+		return err
+	}
+	err = check(multi)
+	if err != nil {
+		return err
+	}
+	_, err = multi.Exec(func() error {
+		return f(multi)
+	})
+	if err == redis.TxFailedErr {
+		return ErrKeyModified
+	}
+	return err
+}
+
+// RedisMGet gets the values of keys, nil for missing keys. Redis Cluster refuses MGET across slots,
+// keys are then read one by one.
+func RedisMGet(r RedisWrapper, keys ...string) ([]interface{}, error) {
+	if _, ok := r.(*redis.ClusterClient); !ok {
+		return r.MGet(keys...).Result()
+	}
+	values := make([]interface{}, len(keys))
+	for i, key := range keys {
+		value, err := r.Get(key).Result()
+		if err == redis.Nil {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		values[i] = value
+	}
+	return values, nil
+}
+
+// RedisDel deletes keys. Redis Cluster refuses DEL across slots, keys are then deleted one by one.
+func RedisDel(r RedisWrapper, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	if _, ok := r.(*redis.ClusterClient); !ok {
+		return r.Del(keys...).Err()
+	}
+	for _, key := range keys {
+		err := r.Del(key).Err()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EscapeRedisPattern escapes the glob characters of a key used in a SCAN pattern
+func EscapeRedisPattern(key string) string {
+	return strings.NewReplacer("\\", "\\\\", "*", "\\*", "?", "\\?", "[", "\\[", "]", "\\]").Replace(key)
+}
+
+// RedisScan calls f with the keys matching the pattern, in batches. SCAN does not block Redis as KEYS does.
+// In cluster mode, SCAN only covers the node it is sent to, every master of the cluster is then scanned.
+func RedisScan(r RedisWrapper, pattern string, f func(keys []string) error) error {
+	if _, ok := r.(*redis.ClusterClient); !ok {
+		return scanNode(r, pattern, f)
+	}
+	nodes, err := r.ClusterNodes().Result()
+	if err != nil {
+		return err
+	}
+	masters := clusterMasters(nodes)
+	if len(masters) == 0 {
+		return errors.New("No master found in the Redis cluster")
+	}
+	for _, addr := range masters {
+		node := redis.NewClient(&redis.Options{
+			Addr:        addr,
+			Password:    clusterPassword,
+			DialTimeout: 1 * time.Minute,
+			ReadTimeout: 2 * time.Minute,
+		})
+		err = scanNode(node, pattern, f)
+		node.Close()
+		if err != nil {
+			return fmt.Errorf("Cannot scan Redis node %s: %s", addr, err)
+		}
+	}
+	return nil
+}
+
+// clusterMasters returns the addresses of the masters listed by CLUSTER NODES, whose lines are
+// <id> <ip:port@cport[,hostname]> <flags> <master> <ping-sent> <pong-recv> <config-epoch> <link-state> <slot>...
+func clusterMasters(nodes string) []string {
+	masters := []string{}
+	for _, line := range strings.Split(nodes, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 {
+			continue
+		}
+		flags := "," + fields[2] + ","
+		if !strings.Contains(flags, ",master,") || strings.Contains(flags, ",fail,") || strings.Contains(flags, ",noaddr,") {
+			continue
+		}
+		addr := strings.SplitN(strings.SplitN(fields[1], ",", 2)[0], "@", 2)[0]
+		masters = append(masters, addr)
+	}
+	return masters
+}
+
+// scanNode calls f with the keys matching the pattern on a single node
+func scanNode(r RedisWrapper, pattern string, f func(keys []string) error) error {
+	cursor := int64(0)
+	for {
+		next, keys, err := r.Scan(cursor, pattern, 100).Result()
+		if err != nil {
+			return err
+		}
+		if len(keys) > 0 {
+			err = f(keys)
+			if err != nil {
+				return err
+			}
+		}
+		if next == 0 {
+			return nil
+		}
+		cursor = next
+	}
+}
@@ -0,0 +1,99 @@
+package manifests
+
+import (
+	log "github.com/Sirupsen/logrus"
+	"github.com/soprasteria/intools-engine/bundles"
+	"github.com/soprasteria/intools-engine/connectors"
+	"github.com/soprasteria/intools-engine/groups"
+)
+
+// ApplyOptions tells how manifests are applied
+type ApplyOptions struct {
+	// Prune removes the connectors applied from manifests which are not declared anymore
+	Prune  bool
+	DryRun bool
+	// Schedule updates the scheduling of the connectors, when the engine runs them
+	Schedule bool
+}
+
+// Apply reconciles the store with the manifests : declared groups and connectors are created or updated.
+// Connectors which were not applied from manifests are left alone.
+func Apply(b *bundles.Bundle, options ApplyOptions) (*bundles.ImportReport, error) {
+	for i := range b.Groups {
+		b.Groups[i].Results = nil
+		for j := range b.Groups[i].Connectors {
+			b.Groups[i].Connectors[j].Managed = true
+		}
+	}
+
+	report, err := bundles.Import(b, bundles.ImportOptions{
+		Mode:     bundles.ModeMerge,
+		DryRun:   options.DryRun,
+		Schedule: options.Schedule,
+	})
+	if err != nil || !options.Prune {
+		return report, err
+	}
+	return report, prune(b, options, report)
+}
+
+// prune removes the managed connectors which are not declared, and their group when it is not declared and left empty
+func prune(b *bundles.Bundle, options ApplyOptions, report *bundles.ImportReport) error {
+	declared := map[string]map[string]bool{}
+	for _, g := range b.Groups {
+		declared[g.Name] = map[string]bool{}
+		for _, conn := range g.Connectors {
+			declared[g.Name][conn.Name] = true
+		}
+	}
+
+	groupNames, err := groups.Repository.GetGroups()
+	if err != nil {
+		return err
+	}
+	for _, group := range groupNames {
+		names, err := connectors.GetConnectorNames(group)
+		if err != nil {
+			return err
+		}
+		pruned := 0
+		for _, name := range names {
+			if declared[group][name] {
+				continue
+			}
+			conn, err := connectors.GetConnector(group, name)
+			if err != nil {
+				return err
+			}
+			if !conn.Managed {
+				continue
+			}
+			pruned++
+			report.Changes = append(report.Changes, bundles.Change{Group: group, Connector: name, Action: bundles.ActionDelete})
+			if options.DryRun {
+				continue
+			}
+			connectors.Scheduler.RemoveJob(conn)
+			err = connectors.RemoveConnector(conn)
+			if err != nil {
+				return err
+			}
+		}
+
+		if _, ok := declared[group]; ok || pruned == 0 || pruned < len(names) {
+			continue
+		}
+		report.Changes = append(report.Changes, bundles.Change{Group: group, Action: bundles.ActionDelete})
+		if options.DryRun {
+			continue
+		}
+		_, err = groups.DeleteGroup(group, false)
+		if err != nil {
+			return err
+		}
+	}
+	if !options.DryRun {
+		log.WithField("changes", len(report.Changes)).Info("Manifests pruned")
+	}
+	return nil
+}
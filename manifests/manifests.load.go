@@ -0,0 +1,143 @@
+package manifests
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/soprasteria/intools-engine/bundles"
+	"github.com/soprasteria/intools-engine/connectors"
+)
+
+// Kinds of manifests
+const (
+	KindGroup     = "Group"
+	KindConnector = "Connector"
+)
+
+// documentSeparator separates the documents of a YAML file
+var documentSeparator = regexp.MustCompile(`(?m)^---\s*$`)
+
+// isManifest tells whether a file of a directory is read as a manifest
+func isManifest(name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	return ext == ".yaml" || ext == ".yml" || ext == ".json"
+}
+
+// listFiles returns the manifests of a directory and its sub-directories, sorted, or the path itself when it is a file
+func listFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+	files := []string{}
+	err = filepath.Walk(path, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && isManifest(file) {
+			files = append(files, file)
+		}
+		return nil
+	})
+	sort.Strings(files)
+	return files, err
+}
+
+// Load reads the manifests of a file or a directory. Connectors are grouped in their group, which does not
+// need a manifest of its own.
+func Load(path string) (*bundles.Bundle, error) {
+	files, err := listFiles(path)
+	if err != nil {
+		return nil, err
+	}
+
+	b := &bundles.Bundle{Version: bundles.Version, ExportedAt: time.Now().UTC(), Groups: []bundles.GroupBundle{}}
+	indexes := map[string]int{}
+	declared := map[string]bool{}
+	group := func(name string) *bundles.GroupBundle {
+		i, ok := indexes[name]
+		if !ok {
+			i = len(b.Groups)
+			indexes[name] = i
+			b.Groups = append(b.Groups, bundles.GroupBundle{Name: name, Connectors: []connectors.Connector{}})
+		}
+		return &b.Groups[i]
+	}
+
+	for _, file := range files {
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		for i, document := range documentSeparator.Split(string(content), -1) {
+			if strings.TrimSpace(document) == "" {
+				continue
+			}
+			err = loadDocument([]byte(document), group, declared)
+			if err != nil {
+				return nil, fmt.Errorf("%s, document %d : %s", file, i+1, err.Error())
+			}
+		}
+	}
+	return b, nil
+}
+
+func loadDocument(document []byte, group func(name string) *bundles.GroupBundle, declared map[string]bool) error {
+	content, err := bundles.YAMLToJSON(document)
+	if err != nil {
+		return err
+	}
+	var header struct {
+		Kind string `json:"kind"`
+	}
+	err = json.Unmarshal(content, &header)
+	if err != nil {
+		return err
+	}
+
+	switch header.Kind {
+	case KindGroup:
+		var g bundles.GroupBundle
+		err = json.Unmarshal(content, &g)
+		if err != nil {
+			return err
+		}
+		if g.Name == "" {
+			return fmt.Errorf("%s without name", KindGroup)
+		}
+		if declared[g.Name] {
+			return fmt.Errorf("%s %s is declared twice", KindGroup, g.Name)
+		}
+		declared[g.Name] = true
+		target := group(g.Name)
+		target.Declared = true
+		target.Metadata = g.Metadata
+		target.Defaults = g.Defaults
+		target.Retention = g.Retention
+		target.Connectors = append(target.Connectors, g.Connectors...)
+	case KindConnector:
+		var conn connectors.Connector
+		err = json.Unmarshal(content, &conn)
+		if err != nil {
+			return err
+		}
+		if conn.Group == "" {
+			return fmt.Errorf("%s %s without group", KindConnector, conn.Name)
+		}
+		target := group(conn.Group)
+		target.Connectors = append(target.Connectors, conn)
+	default:
+		return fmt.Errorf("unknown kind '%s', expected %s or %s", header.Kind, KindGroup, KindConnector)
+	}
+	return nil
+}
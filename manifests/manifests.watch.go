@@ -0,0 +1,83 @@
+package manifests
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/soprasteria/intools-engine/bundles"
+)
+
+// fingerprint changes when a manifest is added, removed or modified
+func fingerprint(path string) (string, error) {
+	files, err := listFiles(path)
+	if err != nil {
+		return "", err
+	}
+	hash := sha1.New()
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(hash, "%s %d %d\n", file, info.Size(), info.ModTime().UnixNano())
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func logReport(report *bundles.ImportReport) {
+	for _, change := range report.Changes {
+		if change.Action == bundles.ActionUnchanged {
+			continue
+		}
+		log.WithFields(log.Fields{"group": change.Group, "connector": change.Connector}).Info("Manifest applied : " + change.Action)
+	}
+}
+
+// Watch applies the manifests of a directory now, then each time they change. Changes are checked every interval.
+func Watch(path string, interval time.Duration, prune bool) {
+	options := ApplyOptions{Prune: prune, Schedule: true}
+	last := ""
+	check := func() {
+		current, err := fingerprint(path)
+		if err != nil {
+			log.WithError(err).WithField("path", path).Error("Cannot read manifests")
+			return
+		}
+		if current == last {
+			return
+		}
+		b, err := Load(path)
+		if err != nil {
+			// Invalid manifests are applied again only once they change
+			log.WithError(err).WithField("path", path).Error("Invalid manifests")
+			last = current
+			return
+		}
+		report, err := Apply(b, options)
+		if report != nil {
+			logReport(report)
+		}
+		if err != nil && report != nil {
+			// The store failed, the manifests are applied again at the next check
+			log.WithError(err).WithField("path", path).Error("Cannot apply manifests")
+			return
+		}
+		if err != nil {
+			log.WithError(err).WithField("path", path).Error("Invalid manifests")
+		}
+		last = current
+	}
+
+	check()
+	ticker := time.NewTicker(interval)
+	go func() {
+		for _ = range ticker.C {
+			check()
+		}
+	}()
+	log.Infof("Manifests of %s will be applied when they change, checked every %s", path, interval)
+}
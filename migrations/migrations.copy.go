@@ -0,0 +1,68 @@
+package migrations
+
+import (
+	"fmt"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/soprasteria/intools-engine/intools"
+)
+
+// CopyPrefix copies the keys starting with a prefix to the same keys starting with another prefix, with their
+// TTL. Existing keys are only replaced with overwrite, and nothing is copied when one exists without it.
+// With dryRun, nothing is copied. It returns the number of keys copied, or to copy.
+func CopyPrefix(r intools.RedisWrapper, from string, to string, overwrite bool, dryRun bool) (int, error) {
+	if from == "" || to == "" || from == to || strings.HasPrefix(from, to+":") || strings.HasPrefix(to, from+":") {
+		return 0, fmt.Errorf("Cannot copy keys from prefix '%s' to prefix '%s'", from, to)
+	}
+
+	keys := []string{}
+	err := intools.RedisScan(r, intools.EscapeRedisPattern(from)+":*", func(scanned []string) error {
+		for _, key := range scanned {
+			if overwrite {
+				continue
+			}
+			exists, err := r.Exists(to + key[len(from):]).Result()
+			if err != nil {
+				return err
+			}
+			if exists {
+				return fmt.Errorf("Key %s already exists", to+key[len(from):])
+			}
+		}
+		keys = append(keys, scanned...)
+		return nil
+	})
+	if err != nil || dryRun {
+		return len(keys), err
+	}
+
+	for i, key := range keys {
+		err = copyKey(r, key, to+key[len(from):])
+		if err != nil {
+			return i, err
+		}
+	}
+	log.WithFields(log.Fields{"from": from, "to": to, "keys": len(keys)}).Info("Keys copied")
+	return len(keys), nil
+}
+
+func copyKey(r intools.RedisWrapper, key string, target string) error {
+	value, err := r.Dump(key).Result()
+	if err != nil {
+		return err
+	}
+	ttl, err := r.PTTL(key).Result()
+	if err != nil {
+		return err
+	}
+	// No expiration is reported as a negative TTL, and given as zero to RESTORE
+	if ttl < 0 {
+		ttl = 0
+	}
+	err = r.Del(target).Err()
+	if err != nil {
+		return err
+	}
+	return r.Restore(target, ttl, value).Err()
+}
@@ -0,0 +1,35 @@
+package migrations
+
+import (
+	"github.com/soprasteria/intools-engine/intools"
+)
+
+// Migration upgrades the data in Redis from the previous version of the key layout to Version.
+// Up returns the changes it made, or the ones it would make with dryRun.
+type Migration struct {
+	Version     int
+	Description string
+	Up          func(r intools.RedisWrapper, dryRun bool) ([]string, error)
+}
+
+// Migrations is the registry of the migrations, ordered by version.
+// Version 1 is the layout of the keys before they were versioned.
+var Migrations = []Migration{
+	{
+		Version:     2,
+		Description: "Membership lists of groups and connectors become sets, stray connector lists are removed",
+		Up:          migrateMembershipSets,
+	},
+}
+
+// CurrentVersion is the version of the key layout used by this engine
+func CurrentVersion() int {
+	return Migrations[len(Migrations)-1].Version
+}
+
+// Step is a migration applied, or to apply in a dry run
+type Step struct {
+	Version     int      `json:"version"`
+	Description string   `json:"description"`
+	Changes     []string `json:"changes"`
+}
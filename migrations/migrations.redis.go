@@ -0,0 +1,94 @@
+package migrations
+
+import (
+	"fmt"
+	"strconv"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/soprasteria/intools-engine/common/keys"
+	"github.com/soprasteria/intools-engine/intools"
+	"gopkg.in/redis.v3"
+)
+
+// legacyVersion is the version of data written before the key layout was versioned
+const legacyVersion = 1
+
+// GetVersion returns the version of the key layout of the data in Redis, zero when Redis holds no data of the engine
+func GetVersion(r intools.RedisWrapper) (int, error) {
+	sVersion, err := r.Get(keys.Schema()).Result()
+	if err == redis.Nil {
+		exists, err := r.Exists(keys.Groups()).Result()
+		if err != nil || !exists {
+			return 0, err
+		}
+		return legacyVersion, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(sVersion)
+}
+
+func setVersion(r intools.RedisWrapper, version int) error {
+	return r.Set(keys.Schema(), strconv.Itoa(version), 0).Err()
+}
+
+// CheckVersion makes sure the engine can use the data in Redis. An empty Redis is marked with the current version.
+func CheckVersion(r intools.RedisWrapper) error {
+	version, err := GetVersion(r)
+	if err != nil {
+		log.WithError(err).Error("Cannot read the version of the Redis key layout")
+		return err
+	}
+	switch {
+	case version == 0:
+		return setVersion(r, CurrentVersion())
+	case version < CurrentVersion():
+		err = fmt.Errorf("Redis data has version %d, engine expects version %d : please run intools migrate", version, CurrentVersion())
+	case version > CurrentVersion():
+		err = fmt.Errorf("Redis data has version %d, newer than version %d of this engine", version, CurrentVersion())
+	}
+	if err != nil {
+		log.WithError(err).Error("Incompatible Redis key layout")
+	}
+	return err
+}
+
+// Migrate applies the migrations newer than the version of the data. With dryRun, nothing is changed and the steps
+// tell what would be done.
+func Migrate(r intools.RedisWrapper, dryRun bool) ([]Step, error) {
+	version, err := GetVersion(r)
+	if err != nil {
+		return nil, err
+	}
+	steps := []Step{}
+	if version == 0 {
+		if !dryRun {
+			err = setVersion(r, CurrentVersion())
+		}
+		return steps, err
+	}
+
+	for _, m := range Migrations {
+		if m.Version <= version {
+			continue
+		}
+		log.WithFields(log.Fields{"version": m.Version, "dryRun": dryRun}).Info("Migrating: " + m.Description)
+		changes, err := m.Up(r, dryRun)
+		if changes == nil {
+			changes = []string{}
+		}
+		steps = append(steps, Step{Version: m.Version, Description: m.Description, Changes: changes})
+		if err != nil {
+			log.WithError(err).WithField("version", m.Version).Error("Migration failed")
+			return steps, err
+		}
+		if !dryRun {
+			err = setVersion(r, m.Version)
+			if err != nil {
+				return steps, err
+			}
+		}
+	}
+	return steps, nil
+}
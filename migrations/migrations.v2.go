@@ -0,0 +1,74 @@
+package migrations
+
+import (
+	"fmt"
+
+	"github.com/soprasteria/intools-engine/common/keys"
+	"github.com/soprasteria/intools-engine/intools"
+)
+
+// listToSet replaces a list by a set of its distinct members, and returns the members
+func listToSet(r intools.RedisWrapper, key string, dryRun bool, changes *[]string) ([]string, error) {
+	keyType, err := r.Type(key).Result()
+	if err != nil {
+		return nil, err
+	}
+	switch keyType {
+	case "set":
+		return r.SMembers(key).Result()
+	case "none":
+		return []string{}, nil
+	case "list":
+	default:
+		return nil, fmt.Errorf("%s is a %s, expected a list", key, keyType)
+	}
+
+	members, err := r.LRange(key, 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+	*changes = append(*changes, fmt.Sprintf("convert list %s to a set of %d members", key, len(members)))
+	if dryRun {
+		return members, nil
+	}
+	err = intools.RedisTransaction(r, func(tx intools.RedisWrapper) error {
+		tx.Del(key)
+		for _, member := range members {
+			tx.SAdd(key, member)
+		}
+		return nil
+	})
+	return members, err
+}
+
+func migrateMembershipSets(r intools.RedisWrapper, dryRun bool) ([]string, error) {
+	changes := []string{}
+	groups, err := listToSet(r, keys.Groups(), dryRun, &changes)
+	if err != nil {
+		return changes, err
+	}
+	for _, group := range groups {
+		connectors, err := listToSet(r, keys.Connectors(group), dryRun, &changes)
+		if err != nil {
+			return changes, err
+		}
+		for _, connector := range connectors {
+			// Version 1 pushed the name of the group in a list at the key of the connector
+			stray := keys.Connector(group, connector)
+			keyType, err := r.Type(stray).Result()
+			if err != nil {
+				return changes, err
+			}
+			if keyType != "list" {
+				continue
+			}
+			changes = append(changes, "remove stray list "+stray)
+			if !dryRun {
+				if err = r.Del(stray).Err(); err != nil {
+					return changes, err
+				}
+			}
+		}
+	}
+	return changes, nil
+}
@@ -0,0 +1,60 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// methods of the operations of an OpenAPI path item
+var methods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+type document struct {
+	Paths map[string]map[string]json.RawMessage `json:"paths"`
+}
+
+// specPath converts the path of a gin route to an OpenAPI path, /groups/:group becoming /groups/{group}
+func specPath(path string) string {
+	parts := strings.Split(path, "/")
+	for i, part := range parts {
+		if strings.HasPrefix(part, ":") || strings.HasPrefix(part, "*") {
+			parts[i] = "{" + part[1:] + "}"
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+// Check compares the routes served under the prefix with the operations of the specification.
+// It returns the routes missing from the specification, and the operations which are not served.
+func Check(routes gin.RoutesInfo, prefix string) ([]string, error) {
+	var doc document
+	if err := json.Unmarshal([]byte(Spec), &doc); err != nil {
+		return nil, fmt.Errorf("invalid specification : %s", err)
+	}
+
+	problems := []string{}
+	served := map[string]bool{}
+	for _, route := range routes {
+		if !strings.HasPrefix(route.Path, prefix+"/") {
+			continue
+		}
+		path := specPath(strings.TrimPrefix(route.Path, prefix))
+		method := strings.ToLower(route.Method)
+		served[method+" "+path] = true
+		if _, ok := doc.Paths[path][method]; !ok {
+			problems = append(problems, fmt.Sprintf("%s %s is missing from the specification", route.Method, route.Path))
+		}
+	}
+	for path, item := range doc.Paths {
+		for _, method := range methods {
+			if _, ok := item[method]; ok && !served[method+" "+path] {
+				problems = append(problems, fmt.Sprintf("%s %s%s is not served", strings.ToUpper(method), prefix, path))
+			}
+		}
+	}
+	sort.Strings(problems)
+	return problems, nil
+}